@@ -0,0 +1,75 @@
+// Command fakebroker runs a local fake mb-broker for development:
+//
+//	go run ./cmd/fakebroker -listen :8090 -rate 6 -bad-phone-rate 0.1 -error-rate 0.05
+//
+// Point the service at it with mb_broker_url=http://localhost:8090.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"mb-feedback/internal/fakebroker"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+)
+
+func main() {
+	listen := flag.String("listen", ":8090", "address to listen on")
+	providers := flag.String("providers", "kaspi", "comma separated prv_id values to generate orders for")
+	rate := flag.Float64("rate", 6, "new orders per minute for every provider, 0 disables generation")
+	initial := flag.Int("initial", 50, "orders per provider generated at start")
+	history := flag.Duration("history", 24*time.Hour, "period the initial orders are spread over")
+	seed := flag.Uint64("seed", uint64(time.Now().UnixNano()), "random seed, fix it for reproducible data")
+	badPhoneRate := flag.Float64("bad-phone-rate", 0.05, "share of orders with a malformed phone")
+	emptyItemsRate := flag.Float64("empty-items-rate", 0.05, "share of orders without items")
+	errorRate := flag.Float64("error-rate", 0, "share of requests answered with 503")
+	token := flag.String("token", "", "accepted bearer token, empty disables auth")
+	flag.Parse()
+
+	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+
+	srv := fakebroker.New(fakebroker.Config{
+		Providers:      strings.Split(*providers, ","),
+		Rate:           *rate,
+		Initial:        *initial,
+		History:        *history,
+		Seed:           *seed,
+		BadPhoneRate:   *badPhoneRate,
+		EmptyItemsRate: *emptyItemsRate,
+		ErrorRate:      *errorRate,
+		Token:          *token,
+	})
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	go srv.Generate(ctx)
+
+	httpServer := &http.Server{
+		Addr:              *listen,
+		Handler:           srv.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer shutdownCancel()
+
+		_ = httpServer.Shutdown(shutdownCtx)
+	}()
+
+	slog.Info("Fake mb-broker started", "listen", *listen, "providers", *providers, "seed", *seed)
+
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("Fake mb-broker failed", "error", err)
+		os.Exit(1)
+	}
+}
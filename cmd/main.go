@@ -2,12 +2,19 @@ package main
 
 import (
 	"mb-feedback/internal/app"
+	"os"
 )
 
 func main() {
 	a := &app.App{}
 
 	a.Init()
+
+	if len(os.Args) > 1 && os.Args[1] == "import" {
+		a.Import(os.Args[2:])
+		a.Exit()
+	}
+
 	a.Start()
 	a.Listen()
 	a.Stop()
@@ -3,11 +3,14 @@ module mb-feedback
 go 1.23.0
 
 require (
-	github.com/Masterminds/squirrel v1.5.4 // indirect
-	github.com/caarlos0/env/v9 v9.0.0 // indirect
+	github.com/Masterminds/squirrel v1.5.4
+	github.com/caarlos0/env/v9 v9.0.0
+	github.com/jackc/pgx/v5 v5.7.2
+)
+
+require (
 	github.com/jackc/pgpassfile v1.0.0 // indirect
 	github.com/jackc/pgservicefile v0.0.0-20240606120523-5a60cdf6a761 // indirect
-	github.com/jackc/pgx/v5 v5.7.2 // indirect
 	github.com/jackc/puddle/v2 v2.2.2 // indirect
 	github.com/lann/builder v0.0.0-20180802200727-47ae307949d0 // indirect
 	github.com/lann/ps v0.0.0-20150810152359-62de8c46ede0 // indirect
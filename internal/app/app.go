@@ -5,23 +5,55 @@ import (
 	"fmt"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"log/slog"
+	"mb-feedback/internal/client/breaker"
+	"mb-feedback/internal/client/cassette"
+	"mb-feedback/internal/client/fetcher"
+	"mb-feedback/internal/client/fetcher/file"
 	mb_broker "mb-feedback/internal/client/fetcher/mb-broker"
+	"mb-feedback/internal/client/fetcher/static"
+	"mb-feedback/internal/client/notifier"
+	"mb-feedback/internal/client/notifier/sandbox"
 	"mb-feedback/internal/client/notifier/voximplant"
+	"mb-feedback/internal/cns"
 	"mb-feedback/internal/conf"
+	customerRepoPG "mb-feedback/internal/domain/customer/repo/pg"
+	CustomerService "mb-feedback/internal/domain/customer/service"
+	feedbackDelayRepoPG "mb-feedback/internal/domain/feedback_delay/repo/pg"
+	FeedbackDelayService "mb-feedback/internal/domain/feedback_delay/service"
+	messageTemplateModel "mb-feedback/internal/domain/message_template/model"
+	messageTemplateRepoPG "mb-feedback/internal/domain/message_template/repo/pg"
+	MessageTemplateService "mb-feedback/internal/domain/message_template/service"
 	notificationRepoPG "mb-feedback/internal/domain/notification/repo/pg"
 	NotificationService "mb-feedback/internal/domain/notification/service"
+	orderModel "mb-feedback/internal/domain/order/model"
 	orderRepoFetcher "mb-feedback/internal/domain/order/repo/fetcher"
 	orderRepoPG "mb-feedback/internal/domain/order/repo/pg"
 	OrderService "mb-feedback/internal/domain/order/service"
 	orderDetailRepoFetcher "mb-feedback/internal/domain/order_detail/repo/fetcher"
 	orderDetailRepoPG "mb-feedback/internal/domain/order_detail/repo/pg"
 	OrderDetailService "mb-feedback/internal/domain/order_detail/service"
+	productRepoPG "mb-feedback/internal/domain/product/repo/pg"
+	ProductService "mb-feedback/internal/domain/product/service"
+	sandboxMessageRepoPG "mb-feedback/internal/domain/sandbox_message/repo/pg"
+	SandboxMessageService "mb-feedback/internal/domain/sandbox_message/service"
+	suppressionRepoPG "mb-feedback/internal/domain/suppression/repo/pg"
+	SuppressionService "mb-feedback/internal/domain/suppression/service"
+	syncCursorRepoPG "mb-feedback/internal/domain/sync_cursor/repo/pg"
+	SyncCursorService "mb-feedback/internal/domain/sync_cursor/service"
+	webhookNonceRepoPG "mb-feedback/internal/domain/webhook_nonce/repo/pg"
+	WebhookNonceService "mb-feedback/internal/domain/webhook_nonce/service"
 	"mb-feedback/internal/handler/rest"
+	CustomerUsecase "mb-feedback/internal/usecase/customer"
 	NotificationUsecase "mb-feedback/internal/usecase/notification"
 	OrderUsecase "mb-feedback/internal/usecase/order"
 	OrderDetailUsecase "mb-feedback/internal/usecase/order_detail"
+	OrderImportUsecase "mb-feedback/internal/usecase/order_import"
+	SuppressionUsecase "mb-feedback/internal/usecase/suppression"
+	"net/http"
 	"os"
 	"os/signal"
+	"slices"
+	"strings"
 	"syscall"
 )
 
@@ -31,18 +63,54 @@ type App struct {
 	mbBrokerClient   *mb_broker.Client
 	voximplantClient *voximplant.Client
 
+	mbBrokerBreaker   *breaker.Breaker
+	voximplantBreaker *breaker.Breaker
+
+	// nil unless http cassettes are enabled
+	mbBrokerTransport   http.RoundTripper
+	voximplantTransport http.RoundTripper
+
+	orderSources *fetcher.Registry
+
+	// customer
+	customerUsc *CustomerUsecase.Usecase
+	customerSrv *CustomerService.Service
+
 	// order
-	orderUsc *OrderUsecase.Usecase
-	orderSrv *OrderService.Service
+	orderUsc      *OrderUsecase.Usecase
+	orderSrv      *OrderService.Service
+	syncCursorSrv *SyncCursorService.Service
+
+	// feedback-delay
+	feedbackDelaySrv *FeedbackDelayService.Service
+
+	// product
+	productSrv *ProductService.Service
 
 	// order-detail
 	orderDetailUsc *OrderDetailUsecase.Usecase
 	orderDetailSrv *OrderDetailService.Service
 
+	// order-import
+	orderImportUsc *OrderImportUsecase.Usecase
+
+	// message-template
+	messageTemplateSrv *MessageTemplateService.Service
+
+	// suppression
+	suppressionUsc *SuppressionUsecase.Usecase
+	suppressionSrv *SuppressionService.Service
+
+	// sandbox-message
+	sandboxMessageSrv *SandboxMessageService.Service
+
 	// notification
 	notificationUsc *NotificationUsecase.Usecase
 	notificationSrv *NotificationService.Service
 
+	// webhook-nonce
+	webhookNonceSrv *WebhookNonceService.Service
+
 	httpServer *rest.Rest
 
 	exitCode int
@@ -63,9 +131,53 @@ func (a *App) Init() {
 		errCheck(err, "pgxpool.New")
 	}
 
+	// circuit breakers
+	{
+		a.mbBrokerBreaker = breaker.New(
+			"mb-broker",
+			conf.Conf.BreakerFailureThreshold,
+			conf.Conf.BreakerOpenTimeout,
+			conf.Conf.BreakerHalfOpenProbes)
+		a.voximplantBreaker = breaker.New(
+			"voximplant",
+			conf.Conf.BreakerFailureThreshold,
+			conf.Conf.BreakerOpenTimeout,
+			conf.Conf.BreakerHalfOpenProbes)
+	}
+
+	// http cassettes
+	{
+		mode := cassette.Mode(conf.Conf.HTTPCassetteMode)
+		if mode != cassette.ModeOff {
+			a.mbBrokerTransport, err = cassette.New(conf.Conf.HTTPCassetteDir, "mb-broker", mode, nil)
+			errCheck(err, "cassette.New mb-broker")
+
+			a.voximplantTransport, err = cassette.New(conf.Conf.HTTPCassetteDir, "voximplant", mode, nil)
+			errCheck(err, "cassette.New voximplant")
+
+			slog.Info("HTTP cassettes enabled", "mode", mode, "dir", conf.Conf.HTTPCassetteDir)
+		}
+	}
+
 	// mb-broker client
 	{
-		a.mbBrokerClient = mb_broker.New(conf.Conf.MbBrokerURL, conf.Conf.MbBrokerToken)
+		tokens, err := newMbBrokerTokenSource(a.mbBrokerTransport)
+		errCheck(err, "newMbBrokerTokenSource")
+
+		a.mbBrokerClient = mb_broker.New(
+			conf.Conf.MbBrokerURL,
+			tokens,
+			conf.Conf.MbBrokerPageSize,
+			conf.Conf.MbBrokerMaxPages,
+			mb_broker.RetryPolicy{
+				MaxRetries: conf.Conf.MbBrokerRetryMax,
+				BaseDelay:  conf.Conf.MbBrokerRetryBaseDelay,
+				MaxDelay:   conf.Conf.MbBrokerRetryMaxDelay,
+				Budget:     conf.Conf.MbBrokerRetryBudget,
+			},
+			a.mbBrokerBreaker,
+			a.mbBrokerTransport,
+			conf.Conf.MbBrokerRateLimit)
 	}
 
 	// voximplant
@@ -75,39 +187,269 @@ func (a *App) Init() {
 			conf.Conf.VoximplantToken,
 			conf.Conf.VoximplantDomainName,
 			conf.Conf.VoximplantTemplateID,
-			conf.Conf.VoximplantChannelID)
+			conf.Conf.VoximplantChannelID,
+			a.voximplantBreaker,
+			a.voximplantTransport)
+	}
+
+	// order sources
+	{
+		a.orderSources = fetcher.NewRegistry()
+
+		for _, provider := range conf.Conf.MbBrokerProviders {
+			source, err := a.newOrderSource(provider)
+			errCheck(err, "newOrderSource")
+
+			a.orderSources.Register(provider.ID, source)
+		}
+	}
+
+	// customer
+	{
+		customerRepoDB := customerRepoPG.New(a.pgpool)
+		a.customerSrv = CustomerService.New(customerRepoDB)
 	}
 
 	// order
 	{
 		orderRepoDB := orderRepoPG.New(a.pgpool)
-		orderFetcherRepo := orderRepoFetcher.New(a.mbBrokerClient)
+		orderFetcherRepo := orderRepoFetcher.New(a.orderSources)
+
+		syncCursorRepoDB := syncCursorRepoPG.New(a.pgpool)
 
 		a.orderSrv = OrderService.New(orderRepoDB, orderFetcherRepo)
-		a.orderUsc = OrderUsecase.New(a.orderSrv)
+		a.syncCursorSrv = SyncCursorService.New(syncCursorRepoDB)
+		providers := make([]*orderModel.Provider, 0, len(conf.Conf.MbBrokerProviders))
+		for _, provider := range conf.Conf.MbBrokerProviders {
+			statusChanges := provider.StatusChanges
+			if statusChanges == nil && (provider.Source == "" || provider.Source == cns.SourceMbBroker) {
+				statusChanges = []string{cns.OrderStatusReturned, cns.OrderStatusCancelled}
+			}
+
+			providers = append(providers, &orderModel.Provider{
+				ID:            provider.ID,
+				Status:        provider.Status,
+				PageSize:      provider.PageSize,
+				StatusChanges: statusChanges,
+			})
+		}
+
+		a.orderUsc = OrderUsecase.New(
+			a.orderSrv,
+			a.syncCursorSrv,
+			a.customerSrv,
+			providers,
+			conf.Conf.SyncCursorOverlap,
+			conf.Conf.StatusChangeWindow)
+	}
+
+	// feedback-delay
+	{
+		feedbackDelayRepoDB := feedbackDelayRepoPG.New(a.pgpool)
+		a.feedbackDelaySrv = FeedbackDelayService.New(feedbackDelayRepoDB, conf.Conf.FeedbackDelay)
+	}
+
+	// product
+	{
+		productRepoDB := productRepoPG.New(a.pgpool)
+		a.productSrv = ProductService.New(productRepoDB)
 	}
 
 	// order-detail
 	{
 		orderDetailRepoDB := orderDetailRepoPG.New(a.pgpool)
-		orderDetailFetcherRepo := orderDetailRepoFetcher.New(a.mbBrokerClient)
+		orderDetailFetcherRepo := orderDetailRepoFetcher.New(a.orderSources)
 		a.orderDetailSrv = OrderDetailService.New(orderDetailRepoDB, orderDetailFetcherRepo)
 
-		a.orderDetailUsc = OrderDetailUsecase.New(a.orderSrv, a.orderDetailSrv)
+		a.orderDetailUsc = OrderDetailUsecase.New(
+			a.orderSrv,
+			a.orderDetailSrv,
+			conf.Conf.ProductCodesWorkers,
+			a.feedbackDelaySrv,
+			a.productSrv)
+	}
+
+	// order-import
+	{
+		providerIDs := make([]string, 0, len(conf.Conf.MbBrokerProviders)+len(conf.Conf.ImportProviders))
+		for _, provider := range conf.Conf.MbBrokerProviders {
+			providerIDs = append(providerIDs, provider.ID)
+		}
+		for _, provider := range conf.Conf.ImportProviders {
+			if provider = strings.TrimSpace(provider); provider != "" && !slices.Contains(providerIDs, provider) {
+				providerIDs = append(providerIDs, provider)
+			}
+		}
+		if conf.Conf.ImportProvider != "" && !slices.Contains(providerIDs, conf.Conf.ImportProvider) {
+			errCheck(fmt.Errorf("import_provider %q is in neither import_providers nor mb_broker_providers", conf.Conf.ImportProvider), "")
+		}
+
+		a.orderImportUsc = OrderImportUsecase.New(a.orderSrv, a.orderDetailSrv, a.customerSrv, a.feedbackDelaySrv, providerIDs, a.productSrv)
+	}
+
+	// customer preferences
+	{
+		a.customerUsc = CustomerUsecase.New(a.orderSrv, a.customerSrv)
+	}
+
+	// message-template
+	{
+		messageTemplateRepoDB := messageTemplateRepoPG.New(a.pgpool)
+
+		// without a matching template the configured Voximplant template is sent with its default params
+		a.messageTemplateSrv = MessageTemplateService.New(messageTemplateRepoDB, &messageTemplateModel.Resolved{
+			TemplateID: conf.Conf.VoximplantTemplateID,
+		})
+	}
+
+	// suppression
+	{
+		suppressionRepoDB := suppressionRepoPG.New(a.pgpool)
+		a.suppressionSrv = SuppressionService.New(suppressionRepoDB)
+
+		a.suppressionUsc = SuppressionUsecase.New(a.orderSrv, a.suppressionSrv)
+	}
+
+	// sandbox-message
+	{
+		sandboxMessageRepoDB := sandboxMessageRepoPG.New(a.pgpool)
+		a.sandboxMessageSrv = SandboxMessageService.New(sandboxMessageRepoDB)
 	}
 
 	// notification
 	{
 		notificationRepoDB := notificationRepoPG.New(a.pgpool)
 
-		a.notificationSrv = NotificationService.New(notificationRepoDB, a.voximplantClient)
-		a.notificationUsc = NotificationUsecase.New(a.orderDetailSrv, a.notificationSrv)
+		messageNotifier, err := a.newNotifier()
+		errCheck(err, "newNotifier")
+
+		a.notificationSrv = NotificationService.New(notificationRepoDB, messageNotifier)
+
+		sendWindow, err := NotificationUsecase.NewSendWindow(
+			conf.Conf.SendWindowStart,
+			conf.Conf.SendWindowEnd,
+			conf.Conf.DefaultTimezone)
+		errCheck(err, "NotificationUsecase.NewSendWindow")
+
+		resendSandboxed := conf.Conf.NotifierResendSandboxed
+		if resendSandboxed && conf.Conf.NotifierMode != "" && conf.Conf.NotifierMode != cns.NotifierModeLive {
+			errCheck(fmt.Errorf("notifier_resend_sandboxed requires the live notifier mode"), "")
+		}
+		if resendSandboxed {
+			slog.Warn("Sandboxed notifications are sent again", "lookback", conf.Conf.NotificationLookback)
+		}
+
+		caps := make([]NotificationUsecase.FrequencyCap, 0, len(conf.Conf.NotificationFrequencyCaps))
+		for _, c := range conf.Conf.NotificationFrequencyCaps {
+			caps = append(caps, NotificationUsecase.FrequencyCap{Period: c.Period, Limit: c.Limit})
+		}
+
+		a.notificationUsc = NotificationUsecase.New(
+			a.orderDetailSrv,
+			a.notificationSrv,
+			a.messageTemplateSrv,
+			conf.Conf.DefaultLocale,
+			NotificationUsecase.RetryPolicy{
+				MaxAttempts: conf.Conf.NotificationMaxAttempts,
+				BaseDelay:   conf.Conf.NotificationRetryBaseDelay,
+				MaxDelay:    conf.Conf.NotificationRetryMaxDelay,
+			},
+			sendWindow,
+			conf.Conf.NotificationLookback,
+			caps,
+			a.suppressionSrv,
+			resendSandboxed)
+	}
+
+	// webhook-nonce
+	{
+		webhookNonceRepoDB := webhookNonceRepoPG.New(a.pgpool)
+		a.webhookNonceSrv = WebhookNonceService.New(webhookNonceRepoDB)
 	}
 
 	// http-server
 	{
-		a.httpServer = rest.New(a.orderUsc, a.orderDetailUsc, a.notificationUsc)
+		a.httpServer = rest.New(
+			a.orderUsc,
+			a.orderDetailUsc,
+			a.notificationUsc,
+			a.orderImportUsc,
+			a.suppressionUsc,
+			a.customerUsc,
+			[]*breaker.Breaker{a.mbBrokerBreaker, a.voximplantBreaker},
+			conf.Conf.WebhookSecret,
+			conf.Conf.WebhookTolerance,
+			conf.Conf.VoximplantCallbackToken,
+			a.webhookNonceSrv,
+			conf.Conf.ImportProvider)
+	}
+}
+
+// newMbBrokerTokenSource builds the mb-broker token source for the configured auth mode.
+func newMbBrokerTokenSource(transport http.RoundTripper) (mb_broker.TokenSource, error) {
+	switch conf.Conf.MbBrokerAuthMode {
+	case "", cns.AuthModeStatic:
+		return mb_broker.StaticToken(conf.Conf.MbBrokerToken), nil
+	case cns.AuthModeOAuth2:
+		if conf.Conf.MbBrokerTokenURL == "" || conf.Conf.MbBrokerClientID == "" {
+			return nil, fmt.Errorf("mb_broker_token_url and mb_broker_client_id are required for the oauth2 auth mode")
+		}
+		return mb_broker.NewOAuth2Token(mb_broker.OAuth2Config{
+			TokenURL:      conf.Conf.MbBrokerTokenURL,
+			ClientID:      conf.Conf.MbBrokerClientID,
+			ClientSecret:  conf.Conf.MbBrokerClientSecret,
+			Scope:         conf.Conf.MbBrokerScope,
+			RefreshBefore: conf.Conf.MbBrokerTokenRefreshBefore,
+		}, transport), nil
 	}
+
+	return nil, fmt.Errorf("unknown mb-broker auth mode %q", conf.Conf.MbBrokerAuthMode)
+}
+
+// newNotifier builds the notifier for the configured notifier mode.
+func (a *App) newNotifier() (notifier.Notifier, error) {
+	switch conf.Conf.NotifierMode {
+	case "", cns.NotifierModeLive:
+		return a.voximplantClient, nil
+	case cns.NotifierModeSandbox:
+		slog.Warn("Notifier is in sandbox mode, no messages are sent")
+		return sandbox.New(a.voximplantClient, a.sandboxMessageSrv, nil, nil), nil
+	case cns.NotifierModeAllowlist:
+		if len(conf.Conf.NotifierAllowlist) == 0 {
+			return nil, fmt.Errorf("notifier_allowlist is required for the allowlist notifier mode")
+		}
+
+		allowlist := make([]string, 0, len(conf.Conf.NotifierAllowlist))
+		for _, phone := range conf.Conf.NotifierAllowlist {
+			normalized, err := a.orderSrv.FormatPhoneNumber(strings.TrimPrefix(strings.TrimSpace(phone), "+"))
+			if err != nil {
+				return nil, fmt.Errorf("notifier_allowlist: %w", err)
+			}
+			allowlist = append(allowlist, normalized)
+		}
+
+		slog.Warn("Notifier is in allowlist mode, only allowlisted phones get messages", "allowlist", allowlist)
+		return sandbox.New(a.voximplantClient, a.sandboxMessageSrv, a.voximplantClient, allowlist), nil
+	}
+
+	return nil, fmt.Errorf("unknown notifier mode %q", conf.Conf.NotifierMode)
+}
+
+// newOrderSource builds the order source configured for the provider.
+func (a *App) newOrderSource(provider conf.ProviderSt) (fetcher.Fetcher, error) {
+	switch provider.Source {
+	case "", cns.SourceMbBroker:
+		return a.mbBrokerClient, nil
+	case cns.SourceFile:
+		if provider.Dir == "" {
+			return nil, fmt.Errorf("provider %s: dir is required for the file source", provider.ID)
+		}
+		return file.New(provider.ID, provider.Dir), nil
+	case cns.SourceStatic:
+		return static.New(), nil
+	}
+
+	return nil, fmt.Errorf("provider %s: unknown order source %q", provider.ID, provider.Source)
 }
 
 func (a *App) Start() {
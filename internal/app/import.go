@@ -0,0 +1,59 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log/slog"
+	"mb-feedback/internal/conf"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Import runs the order import subcommand:
+//
+//	svc import [-provider offline] [-format csv|jsonl] <file>
+//
+// The per-row report is written to stdout as JSON.
+func (a *App) Import(args []string) {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	provider := flags.String("provider", conf.Conf.ImportProvider, "provider the orders belong to")
+	format := flags.String("format", "", "file format: csv or jsonl (default: by file extension)")
+
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		flags.Usage()
+		a.exitCode = 2
+		return
+	}
+
+	path := flags.Arg(0)
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		slog.Error("Failed to open import file", "error", err)
+		a.exitCode = 1
+		return
+	}
+	defer f.Close()
+
+	report, err := a.orderImportUsc.Import(context.Background(), *provider, f, *format)
+	if err != nil {
+		slog.Error("Failed to import orders", "error", err)
+		a.exitCode = 1
+		return
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err = encoder.Encode(report); err != nil {
+		slog.Error("Failed to write import report", "error", err)
+		a.exitCode = 1
+		return
+	}
+
+	slog.Info("Imported orders", "accepted", report.Accepted, "duplicate", report.Duplicate, "rejected", report.Rejected)
+}
@@ -0,0 +1,165 @@
+// Package breaker implements a circuit breaker guarding calls to external services.
+// The breaker opens after a number of consecutive failures and fails fast with
+// errs.CircuitOpen until the open timeout passes, then lets a few probe calls
+// through (half-open) to check whether the service has recovered.
+package breaker
+
+import (
+	"fmt"
+	"log/slog"
+	"mb-feedback/internal/errs"
+	"sync"
+	"time"
+)
+
+type State string
+
+const (
+	StateClosed   State = "CLOSED"
+	StateOpen     State = "OPEN"
+	StateHalfOpen State = "HALF_OPEN"
+)
+
+// Snapshot is a point-in-time view of the breaker state.
+type Snapshot struct {
+	Name     string
+	State    State
+	Failures int
+	OpenedAt time.Time
+}
+
+type Breaker struct {
+	name             string
+	failureThreshold int
+	openTimeout      time.Duration
+	halfOpenProbes   int
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	probes   int
+}
+
+// New creates a breaker that opens after failureThreshold consecutive failures.
+// A failureThreshold of 0 disables the breaker.
+func New(name string, failureThreshold int, openTimeout time.Duration, halfOpenProbes int) *Breaker {
+	if halfOpenProbes <= 0 {
+		halfOpenProbes = 1
+	}
+
+	return &Breaker{
+		name:             name,
+		failureThreshold: failureThreshold,
+		openTimeout:      openTimeout,
+		halfOpenProbes:   halfOpenProbes,
+		state:            StateClosed,
+	}
+}
+
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// Allow reports whether a call may proceed. Every allowed call must be
+// followed by exactly one of Success, Failure or Release.
+func (b *Breaker) Allow() error {
+	if b.failureThreshold <= 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateOpen {
+		if time.Since(b.openedAt) < b.openTimeout {
+			return fmt.Errorf("%s: %w", b.name, errs.CircuitOpen)
+		}
+		b.setState(StateHalfOpen)
+	}
+
+	if b.state == StateHalfOpen {
+		if b.probes >= b.halfOpenProbes {
+			return fmt.Errorf("%s: %w", b.name, errs.CircuitOpen)
+		}
+		b.probes++
+	}
+
+	return nil
+}
+
+// Success records a successful call and closes the breaker if it was probing.
+func (b *Breaker) Success() {
+	if b.failureThreshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	if b.state == StateHalfOpen {
+		b.setState(StateClosed)
+	}
+}
+
+// Failure records a failed call and opens the breaker once the threshold is reached
+// or a half-open probe fails.
+func (b *Breaker) Failure() {
+	if b.failureThreshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	if b.state == StateHalfOpen || b.failures >= b.failureThreshold {
+		b.openedAt = time.Now()
+		b.setState(StateOpen)
+	}
+}
+
+// Release finishes a call whose outcome says nothing about the service health,
+// e.g. cancelled by the caller.
+func (b *Breaker) Release() {
+	if b.failureThreshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateHalfOpen && b.probes > 0 {
+		b.probes--
+	}
+}
+
+func (b *Breaker) Snapshot() Snapshot {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	state := b.state
+	if state == StateOpen && time.Since(b.openedAt) >= b.openTimeout {
+		state = StateHalfOpen
+	}
+
+	return Snapshot{
+		Name:     b.name,
+		State:    state,
+		Failures: b.failures,
+		OpenedAt: b.openedAt,
+	}
+}
+
+// setState must be called with mu held.
+func (b *Breaker) setState(state State) {
+	if b.state == state {
+		return
+	}
+
+	slog.Info("Circuit breaker state changed", "name", b.name, "from", b.state, "to", state)
+
+	b.state = state
+	b.probes = 0
+}
@@ -0,0 +1,243 @@
+// Package cassette records HTTP interactions of external clients to files and
+// replays them later, so the pipeline can run offline against realistic payloads.
+// Secrets (auth headers, access tokens, client secrets) are redacted before
+// anything is written to disk, and requests are matched with secrets redacted too.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+type Mode string
+
+const (
+	// ModeOff passes requests through untouched.
+	ModeOff Mode = "off"
+	// ModeRecord passes requests through and saves every interaction.
+	ModeRecord Mode = "record"
+	// ModeReplay serves saved interactions and never touches the network.
+	ModeReplay Mode = "replay"
+)
+
+// Interaction is one recorded request with its response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+type Request struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+type Response struct {
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+type file struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Recorder is an http.RoundTripper recording to or replaying from one cassette file.
+type Recorder struct {
+	path string
+	mode Mode
+	next http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []*Interaction
+	// replayed counts served interactions per request key, so repeated
+	// identical requests get the recorded responses in their original order
+	replayed map[string]int
+}
+
+// New creates a recorder for the cassette <dir>/<name>.json.
+// In replay mode the cassette must exist; in record mode it is overwritten.
+// next is the transport used in record and off modes, nil means http.DefaultTransport.
+func New(dir, name string, mode Mode, next http.RoundTripper) (*Recorder, error) {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	r := &Recorder{
+		path:     filepath.Join(dir, name+".json"),
+		mode:     mode,
+		next:     next,
+		replayed: make(map[string]int),
+	}
+
+	switch mode {
+	case ModeOff, ModeRecord:
+	case ModeReplay:
+		data, err := os.ReadFile(r.path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cassette: %w", err)
+		}
+
+		f := &file{}
+		if err = json.Unmarshal(data, f); err != nil {
+			return nil, fmt.Errorf("failed to parse cassette %s: %w", r.path, err)
+		}
+		r.interactions = f.Interactions
+	default:
+		return nil, fmt.Errorf("unknown cassette mode %q", mode)
+	}
+
+	return r, nil
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if r.mode == ModeOff {
+		return r.next.RoundTrip(req)
+	}
+
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+	}
+
+	recReq := Request{
+		Method: req.Method,
+		URL:    redactURL(req.URL),
+		Header: redactHeader(req.Header),
+		Body:   redactBody(req.Header.Get("Content-Type"), reqBody),
+	}
+
+	if r.mode == ModeReplay {
+		return r.replay(req, &recReq)
+	}
+
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	req.ContentLength = int64(len(reqBody))
+
+	return r.record(req, &recReq)
+}
+
+func (r *Recorder) record(req *http.Request, recReq *Request) (*http.Response, error) {
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	repBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(repBody))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.interactions = append(r.interactions, &Interaction{
+		Request: *recReq,
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     responseHeader(resp.Header),
+			Body:       redactBody(resp.Header.Get("Content-Type"), repBody),
+		},
+	})
+
+	if err = r.save(); err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, recReq *Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := recReq.key()
+
+	skip := r.replayed[key]
+	for _, v := range r.interactions {
+		if v.Request.key() != key {
+			continue
+		}
+		if skip > 0 {
+			skip--
+			continue
+		}
+
+		r.replayed[key]++
+
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", v.Response.StatusCode, http.StatusText(v.Response.StatusCode)),
+			StatusCode:    v.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        v.Response.Header.Clone(),
+			Body:          io.NopCloser(bytes.NewBufferString(v.Response.Body)),
+			ContentLength: int64(len(v.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("cassette %s: no recorded interaction for %s %s", r.path, recReq.Method, recReq.URL)
+}
+
+func (r *Recorder) save() error {
+	data, err := json.MarshalIndent(&file{Interactions: r.interactions}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("json.MarshalIndent: %w", err)
+	}
+
+	if err = os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cassette dir: %w", err)
+	}
+
+	// write through a temp file so an interrupted run does not leave a broken cassette
+	tmp := r.path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+
+	if err = os.Rename(tmp, r.path); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+
+	return nil
+}
+
+// key identifies the request for replay. The host is left out, so cassettes
+// recorded against one environment replay with any base URL.
+func (r *Request) key() string {
+	path := r.URL
+	if u, err := url.Parse(r.URL); err == nil {
+		path = u.RequestURI()
+	}
+
+	return r.Method + " " + path + "\n" + r.Body
+}
+
+// responseHeader drops headers that no longer match the redacted body.
+func responseHeader(h http.Header) http.Header {
+	result := redactHeader(h)
+	if result != nil {
+		result.Del("Content-Length")
+		result.Del("Date")
+	}
+
+	return result
+}
@@ -0,0 +1,214 @@
+package cassette
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecordRedactsSecrets(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"access_token":"issued-token-value","token_type":"Bearer","expires_in":3600}`)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+
+	rec, err := New(dir, "auth", ModeRecord, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_secret": {"client-secret-value"},
+	}
+	req, err := http.NewRequest(http.MethodPost, srv.URL+"/oauth/token?access_token=query-token-value", strings.NewReader(form.Encode()))
+	if err != nil {
+		t.Fatalf("http.NewRequest: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer header-token-value")
+
+	resp, err := (&http.Client{Transport: rec}).Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	// the caller still gets the real response, only the cassette is redacted
+	if !strings.Contains(string(body), "issued-token-value") {
+		t.Errorf("response body = %s, want the issued token", body)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "auth.json"))
+	if err != nil {
+		t.Fatalf("os.ReadFile: %v", err)
+	}
+
+	for _, secret := range []string{"issued-token-value", "client-secret-value", "query-token-value", "header-token-value"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette contains secret %q", secret)
+		}
+	}
+
+	f := &file{}
+	if err = json.Unmarshal(data, f); err != nil {
+		t.Fatalf("json.Unmarshal: %v", err)
+	}
+	if len(f.Interactions) != 1 {
+		t.Fatalf("got %d interactions, want 1", len(f.Interactions))
+	}
+
+	v := f.Interactions[0]
+	if got := v.Request.Header.Get("Authorization"); got != redacted {
+		t.Errorf("Authorization = %q, want %q", got, redacted)
+	}
+	if got, _ := url.ParseQuery(v.Request.Body); got.Get("client_secret") != redacted || got.Get("grant_type") != "client_credentials" {
+		t.Errorf("request body = %q, want client_secret redacted and grant_type kept", v.Request.Body)
+	}
+	if u, _ := url.Parse(v.Request.URL); u.Query().Get("access_token") != redacted {
+		t.Errorf("request url = %q, want access_token redacted", v.Request.URL)
+	}
+
+	repObj := map[string]any{}
+	if err = json.Unmarshal([]byte(v.Response.Body), &repObj); err != nil {
+		t.Fatalf("json.Unmarshal response body: %v", err)
+	}
+	if repObj["access_token"] != redacted || repObj["token_type"] != "Bearer" {
+		t.Errorf("response body = %s, want access_token redacted and token_type kept", v.Response.Body)
+	}
+}
+
+func TestReplayOrder(t *testing.T) {
+	dir := t.TempDir()
+
+	writeCassette(t, dir, "orders", []*Interaction{
+		interaction(http.MethodGet, "http://recorded.example/ord?page=1", "", `{"page":1,"call":1}`),
+		interaction(http.MethodGet, "http://recorded.example/ord?page=2", "", `{"page":2}`),
+		interaction(http.MethodGet, "http://recorded.example/ord?page=1", "", `{"page":1,"call":2}`),
+		interaction(http.MethodPost, "http://recorded.example/ord/product_codes", `{"prv_code":"1"}`, `["a"]`),
+		interaction(http.MethodPost, "http://recorded.example/ord/product_codes", `{"prv_code":"2"}`, `["b"]`),
+	})
+
+	rec, err := New(dir, "orders", ModeReplay, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	client := &http.Client{Transport: rec}
+
+	// the host is not part of the match and repeated requests are served in recorded order
+	steps := []struct {
+		method string
+		url    string
+		body   string
+		want   string
+	}{
+		{http.MethodGet, "http://other.example/ord?page=1", "", `{"page":1,"call":1}`},
+		{http.MethodPost, "http://other.example/ord/product_codes", `{"prv_code":"2"}`, `["b"]`},
+		{http.MethodGet, "http://other.example/ord?page=1", "", `{"page":1,"call":2}`},
+		{http.MethodGet, "http://other.example/ord?page=2", "", `{"page":2}`},
+		{http.MethodPost, "http://other.example/ord/product_codes", `{"prv_code":"1"}`, `["a"]`},
+	}
+	for i, step := range steps {
+		if got := doReplay(t, client, step.method, step.url, step.body); got != step.want {
+			t.Errorf("step %d: %s %s = %s, want %s", i, step.method, step.url, got, step.want)
+		}
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "http://other.example/ord?page=1", nil)
+	if _, err = client.Do(req); err == nil {
+		t.Errorf("third GET page=1 succeeded, want an error once the recorded responses are used up")
+	}
+}
+
+func TestReplayMatchesRedactedSecrets(t *testing.T) {
+	dir := t.TempDir()
+
+	writeCassette(t, dir, "auth", []*Interaction{
+		interaction(http.MethodPost, "http://recorded.example/oauth/token", "client_secret=REDACTED&grant_type=client_credentials", `{"access_token":"REDACTED"}`),
+	})
+
+	rec, err := New(dir, "auth", ModeReplay, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	req, _ := http.NewRequest(http.MethodPost, "http://other.example/oauth/token", strings.NewReader("grant_type=client_credentials&client_secret=another-secret"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := (&http.Client{Transport: rec}).Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+}
+
+func interaction(method, rawURL, reqBody, repBody string) *Interaction {
+	return &Interaction{
+		Request: Request{
+			Method: method,
+			URL:    rawURL,
+			Body:   reqBody,
+		},
+		Response: Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{"Content-Type": {"application/json"}},
+			Body:       repBody,
+		},
+	}
+}
+
+func writeCassette(t *testing.T, dir, name string, interactions []*Interaction) {
+	t.Helper()
+
+	data, err := json.Marshal(&file{Interactions: interactions})
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	if err = os.WriteFile(filepath.Join(dir, name+".json"), data, 0o644); err != nil {
+		t.Fatalf("os.WriteFile: %v", err)
+	}
+}
+
+func doReplay(t *testing.T, client *http.Client, method, rawURL, body string) string {
+	t.Helper()
+
+	var reqBody io.Reader
+	if body != "" {
+		reqBody = strings.NewReader(body)
+	}
+
+	req, err := http.NewRequest(method, rawURL, reqBody)
+	if err != nil {
+		t.Fatalf("http.NewRequest: %v", err)
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("io.ReadAll: %v", err)
+	}
+
+	return string(data)
+}
@@ -0,0 +1,110 @@
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const redacted = "REDACTED"
+
+// secretHeaders are dropped values of request and response headers.
+var secretHeaders = []string{
+	"Authorization",
+	"Cookie",
+	"Set-Cookie",
+	"X-Signature",
+}
+
+// secretParams are redacted in query strings, form bodies and JSON bodies.
+var secretParams = map[string]struct{}{
+	"access_token":  {},
+	"refresh_token": {},
+	"client_secret": {},
+	"token":         {},
+	"password":      {},
+}
+
+func redactHeader(h http.Header) http.Header {
+	if len(h) == 0 {
+		return nil
+	}
+
+	result := h.Clone()
+	for _, name := range secretHeaders {
+		if result.Get(name) != "" {
+			result.Set(name, redacted)
+		}
+	}
+
+	return result
+}
+
+func redactURL(u *url.URL) string {
+	c := *u
+	c.RawQuery = redactValues(c.Query()).Encode()
+	c.User = nil
+
+	return c.String()
+}
+
+func redactValues(values url.Values) url.Values {
+	for name := range values {
+		if _, ok := secretParams[strings.ToLower(name)]; ok {
+			values[name] = []string{redacted}
+		}
+	}
+
+	return values
+}
+
+func redactBody(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err == nil {
+			return redactValues(values).Encode()
+		}
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") || json.Valid(body):
+		// UseNumber keeps numbers as they were sent instead of rounding them through float64
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+
+		var obj any
+		if err := dec.Decode(&obj); err == nil {
+			if data, err := json.Marshal(redactJSON(obj)); err == nil {
+				return string(data)
+			}
+		}
+	}
+
+	return string(body)
+}
+
+func redactJSON(obj any) any {
+	switch v := obj.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if _, ok := secretParams[strings.ToLower(key)]; ok {
+				v[key] = redacted
+				continue
+			}
+			v[key] = redactJSON(value)
+		}
+	case []any:
+		for i := range v {
+			v[i] = redactJSON(v[i])
+		}
+	}
+
+	return obj
+}
@@ -0,0 +1,173 @@
+package file
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mb-feedback/internal/errs"
+	"strings"
+	"time"
+)
+
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+// productCodesSeparator separates product codes inside a single CSV cell.
+const productCodesSeparator = ";"
+
+// Row is a single order read from a CSV or JSONL file.
+// Err is set when the row is malformed; other rows are still returned.
+type Row struct {
+	Line            int
+	ExternalOrderID string
+	Phone           string
+	Name            string
+	ProductCodes    []string
+	CompletedAt     time.Time
+	Err             error
+}
+
+type rowSt struct {
+	ExternalOrderID string   `json:"external_order_id"`
+	Phone           string   `json:"phone"`
+	Name            string   `json:"name"`
+	ProductCodes    []string `json:"product_codes"`
+	CompletedAt     string   `json:"completed_at"`
+}
+
+// Parse reads rows in the given format.
+func Parse(r io.Reader, format string) ([]*Row, error) {
+	switch format {
+	case FormatCSV:
+		return ParseCSV(r)
+	case FormatJSONL:
+		return ParseJSONL(r)
+	}
+
+	return nil, fmt.Errorf("%w: unknown format %q", errs.InvalidInput, format)
+}
+
+// ParseCSV reads rows from CSV with a header line. The columns are
+// external_order_id, phone, name, product_codes (separated by ";") and an optional completed_at in RFC 3339.
+func ParseCSV(r io.Reader) ([]*Row, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read csv header: %s", errs.InvalidInput, err.Error())
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"external_order_id", "phone", "name", "product_codes"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("%w: csv column %s is missing", errs.InvalidInput, name)
+		}
+	}
+
+	cell := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var result []*Row
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				result = append(result, &Row{Line: line, Err: err})
+				continue
+			}
+			return nil, err
+		}
+
+		var productCodes []string
+		for _, code := range strings.Split(cell(record, "product_codes"), productCodesSeparator) {
+			if code = strings.TrimSpace(code); code != "" {
+				productCodes = append(productCodes, code)
+			}
+		}
+
+		result = append(result, newRow(line, &rowSt{
+			ExternalOrderID: cell(record, "external_order_id"),
+			Phone:           cell(record, "phone"),
+			Name:            cell(record, "name"),
+			ProductCodes:    productCodes,
+			CompletedAt:     cell(record, "completed_at"),
+		}))
+	}
+
+	return result, nil
+}
+
+// ParseJSONL reads rows from JSON Lines, one object per line with the same keys as the CSV columns.
+func ParseJSONL(r io.Reader) ([]*Row, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var result []*Row
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		obj := &rowSt{}
+		if err := json.Unmarshal([]byte(text), obj); err != nil {
+			result = append(result, &Row{Line: line, Err: fmt.Errorf("bad json: %w", err)})
+			continue
+		}
+
+		result = append(result, newRow(line, obj))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func newRow(line int, obj *rowSt) *Row {
+	row := &Row{
+		Line:            line,
+		ExternalOrderID: strings.TrimSpace(obj.ExternalOrderID),
+		Phone:           strings.TrimSpace(obj.Phone),
+		Name:            strings.TrimSpace(obj.Name),
+		ProductCodes:    obj.ProductCodes,
+	}
+
+	switch {
+	case row.ExternalOrderID == "":
+		row.Err = errors.New("external_order_id is required")
+	case row.Phone == "":
+		row.Err = errors.New("phone is required")
+	case len(row.ProductCodes) == 0:
+		row.Err = errors.New("product_codes are required")
+	}
+
+	if obj.CompletedAt != "" {
+		completedAt, err := time.Parse(time.RFC3339, obj.CompletedAt)
+		if err != nil && row.Err == nil {
+			row.Err = fmt.Errorf("bad completed_at: %w", err)
+		}
+		row.CompletedAt = completedAt
+	}
+
+	return row
+}
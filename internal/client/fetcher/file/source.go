@@ -0,0 +1,209 @@
+// Package file implements an order source reading CSV and JSONL files
+// dropped into a directory. Files are never modified; the sync cursor and
+// order deduplication keep repeated reads from importing orders twice.
+// When an order appears in several rows, the first one read wins.
+package file
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	orderModel "mb-feedback/internal/domain/order/model"
+	orderDetailModel "mb-feedback/internal/domain/order_detail/model"
+	"mb-feedback/internal/errs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Source struct {
+	providerID string
+	dir        string
+
+	mu       sync.Mutex
+	snapshot *snapshot
+}
+
+// snapshot is the parsed content of the directory, kept until any file changes.
+type snapshot struct {
+	signature string
+	rows      []*Row
+	byOrder   map[orderKey]*Row
+}
+
+type orderKey struct {
+	provider        string
+	externalOrderID string
+}
+
+// New creates the source of the provider orders stored in dir.
+func New(providerID, dir string) *Source {
+	return &Source{
+		providerID: providerID,
+		dir:        dir,
+	}
+}
+
+// FetchCompletedOrders returns the orders completed at or after completedAfter.
+// Rows without completed_at have no completion time to compare, so they are
+// always returned with a zero CompletedAt; the order service skips the ones
+// already imported and the sync cursor ignores them.
+func (s *Source) FetchCompletedOrders(ctx context.Context, provider *orderModel.Provider, completedAfter *time.Time) ([]*orderModel.Order, error) {
+	snap, err := s.load(ctx, provider.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*orderModel.Order, 0, len(snap.rows))
+	for _, row := range snap.rows {
+		if completedAfter != nil && !row.CompletedAt.IsZero() && row.CompletedAt.Before(*completedAfter) {
+			continue
+		}
+
+		result = append(result, &orderModel.Order{
+			Provider:        provider.ID,
+			ExternalOrderID: row.ExternalOrderID,
+			UserPhone:       row.Phone,
+			UserName:        row.Name,
+			CompletedAt:     row.CompletedAt,
+		})
+	}
+
+	return result, nil
+}
+
+func (s *Source) FetchOrderItems(ctx context.Context, providerID, orderID string) ([]*orderDetailModel.OrderDetail, error) {
+	snap, err := s.load(ctx, providerID)
+	if err != nil {
+		return nil, err
+	}
+
+	row, ok := snap.byOrder[orderKey{provider: providerID, externalOrderID: orderID}]
+	if !ok {
+		return nil, fmt.Errorf("order %s: %w", orderID, errs.ObjectNotFound)
+	}
+
+	result := make([]*orderDetailModel.OrderDetail, 0, len(row.ProductCodes))
+	for _, code := range row.ProductCodes {
+		result = append(result, &orderDetailModel.OrderDetail{
+			ProductCode: code,
+			Quantity:    1,
+		})
+	}
+
+	return result, nil
+}
+
+// load returns the parsed directory. Files are parsed again only when
+// the set of files, their sizes or modification times have changed,
+// so one import run parses every file once.
+func (s *Source) load(ctx context.Context, providerID string) (*snapshot, error) {
+	if providerID != s.providerID {
+		return nil, fmt.Errorf("%w: file source of provider %s asked for provider %s", errs.InvalidInput, s.providerID, providerID)
+	}
+
+	files, signature, err := s.listFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.snapshot != nil && s.snapshot.signature == signature {
+		return s.snapshot, nil
+	}
+
+	snap := &snapshot{
+		signature: signature,
+		byOrder:   make(map[orderKey]*Row),
+	}
+	for _, name := range files {
+		if err = ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		rows, err := s.readFile(filepath.Join(s.dir, name), fileFormat(name))
+		if err != nil {
+			slog.Error("Failed to read order file", "file", name, "error", err)
+			continue
+		}
+
+		for _, row := range rows {
+			key := orderKey{provider: s.providerID, externalOrderID: row.ExternalOrderID}
+			if _, ok := snap.byOrder[key]; ok {
+				continue
+			}
+			snap.byOrder[key] = row
+			snap.rows = append(snap.rows, row)
+		}
+	}
+
+	s.snapshot = snap
+
+	return snap, nil
+}
+
+// listFiles returns the *.csv and *.jsonl files of the directory and a signature
+// that changes whenever any of them is added, removed or modified.
+func (s *Source) listFiles() ([]string, string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, "", fmt.Errorf("os.ReadDir: %w", err)
+	}
+
+	var (
+		files     []string
+		signature strings.Builder
+	)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		format := fileFormat(entry.Name())
+		if format != FormatCSV && format != FormatJSONL {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, "", fmt.Errorf("entry.Info: %w", err)
+		}
+
+		files = append(files, entry.Name())
+		fmt.Fprintf(&signature, "%s:%d:%d;", entry.Name(), info.Size(), info.ModTime().UnixNano())
+	}
+
+	return files, signature.String(), nil
+}
+
+func fileFormat(name string) string {
+	return strings.TrimPrefix(strings.ToLower(filepath.Ext(name)), ".")
+}
+
+func (s *Source) readFile(path, format string) ([]*Row, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	rows, err := Parse(f, format)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*Row, 0, len(rows))
+	for _, row := range rows {
+		if row.Err != nil {
+			slog.Warn("Skipped malformed order row", "file", filepath.Base(path), "line", row.Line, "error", row.Err)
+			continue
+		}
+		result = append(result, row)
+	}
+
+	return result, nil
+}
@@ -3,9 +3,12 @@ package fetcher
 import (
 	"context"
 	orderModel "mb-feedback/internal/domain/order/model"
+	orderDetailModel "mb-feedback/internal/domain/order_detail/model"
+	"time"
 )
 
+// Fetcher is a source of completed orders and their line items.
 type Fetcher interface {
-	FetchCompletedOrders(ctx context.Context) ([]*orderModel.Order, error)
-	FetchProductCodesByOrder(ctx context.Context, orderID string) ([]string, error)
+	FetchCompletedOrders(ctx context.Context, provider *orderModel.Provider, completedAfter *time.Time) ([]*orderModel.Order, error)
+	FetchOrderItems(ctx context.Context, providerID, orderID string) ([]*orderDetailModel.OrderDetail, error)
 }
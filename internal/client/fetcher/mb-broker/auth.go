@@ -0,0 +1,138 @@
+package mb_broker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TokenSource provides the bearer token sent with every mb-broker request.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+	// Invalidate drops the cached token after mb-broker rejected it with 401.
+	Invalidate()
+}
+
+// StaticToken is a fixed bearer token, e.g. from the mb_broker_token env var.
+type StaticToken string
+
+func (t StaticToken) Token(context.Context) (string, error) {
+	return string(t), nil
+}
+
+func (t StaticToken) Invalidate() {}
+
+// OAuth2Config configures the OAuth2 client-credentials grant.
+type OAuth2Config struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scope        string
+	// RefreshBefore is how long before the expiry the token is refreshed.
+	RefreshBefore time.Duration
+}
+
+type tokenRepSt struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// OAuth2Token fetches tokens from the token endpoint with the client-credentials grant
+// and caches them until RefreshBefore ahead of their expiry.
+type OAuth2Token struct {
+	client http.Client
+	cfg    OAuth2Config
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// NewOAuth2Token creates the token source. transport is used for token requests,
+// nil means http.DefaultTransport.
+func NewOAuth2Token(cfg OAuth2Config, transport http.RoundTripper) *OAuth2Token {
+	return &OAuth2Token{
+		client: http.Client{Transport: transport, Timeout: 30 * time.Second},
+		cfg:    cfg,
+	}
+}
+
+func (t *OAuth2Token) Token(ctx context.Context) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.token != "" && time.Now().Add(t.cfg.RefreshBefore).Before(t.expiresAt) {
+		return t.token, nil
+	}
+
+	token, expiresAt, err := t.fetch(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch oauth2 token: %w", err)
+	}
+
+	t.token, t.expiresAt = token, expiresAt
+
+	return token, nil
+}
+
+func (t *OAuth2Token) Invalidate() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.token = ""
+	t.expiresAt = time.Time{}
+}
+
+func (t *OAuth2Token) fetch(ctx context.Context) (string, time.Time, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if t.cfg.Scope != "" {
+		form.Set("scope", t.cfg.Scope)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("http.NewRequestWithContext: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(t.cfg.ClientID), url.QueryEscape(t.cfg.ClientSecret))
+
+	now := time.Now()
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("client.Do: %w", err)
+	}
+	defer resp.Body.Close()
+
+	repBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("resp.Body.ReadAll: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return "", time.Time{}, newAPIError(resp, repBody)
+	}
+
+	repObj := &tokenRepSt{}
+	if err = json.Unmarshal(repBody, repObj); err != nil {
+		return "", time.Time{}, fmt.Errorf("json.Unmarshal: %w", err)
+	}
+	if repObj.AccessToken == "" {
+		return "", time.Time{}, fmt.Errorf("empty access_token")
+	}
+
+	// without expires_in the token is kept until mb-broker rejects it
+	expiresAt := now.Add(100 * 365 * 24 * time.Hour)
+	if repObj.ExpiresIn > 0 {
+		expiresAt = now.Add(time.Duration(repObj.ExpiresIn) * time.Second)
+	}
+
+	return repObj.AccessToken, expiresAt, nil
+}
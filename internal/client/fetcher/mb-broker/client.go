@@ -7,69 +7,143 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"mb-feedback/internal/client/breaker"
+	"mb-feedback/internal/cns"
 	orderModel "mb-feedback/internal/domain/order/model"
+	orderDetailModel "mb-feedback/internal/domain/order_detail/model"
 	"mb-feedback/internal/errs"
 	"net/http"
 	"net/url"
+	"strconv"
+	"time"
 )
 
+const defaultPageSize = 100
+
 type Client struct {
-	client  http.Client
-	baseURL string
-	token   string
+	client   http.Client
+	baseURL  string
+	tokens   TokenSource
+	pageSize int
+	maxPages int
+	retry    RetryPolicy
+	breaker  *breaker.Breaker
+	limiter  *limiter
 }
 
-func New(baseURL, token string) *Client {
+// New creates the client. transport is used for all requests, nil means http.DefaultTransport.
+// rateLimit caps the requests per second sent to mb-broker, retries included (0 means no limit).
+func New(baseURL string, tokens TokenSource, pageSize, maxPages int, retry RetryPolicy, breaker *breaker.Breaker, transport http.RoundTripper, rateLimit float64) *Client {
 	return &Client{
-		client:  http.Client{},
-		baseURL: baseURL,
-		token:   token,
+		client:   http.Client{Transport: transport},
+		baseURL:  baseURL,
+		tokens:   tokens,
+		pageSize: pageSize,
+		maxPages: maxPages,
+		retry:    retry,
+		breaker:  breaker,
+		limiter:  newLimiter(rateLimit),
 	}
 }
 
-func (c *Client) FetchCompletedOrders(ctx context.Context) ([]*orderModel.Order, error) {
+// FetchCompletedOrders walks all pages of the provider orders in the provider status until TotalCount is reached.
+// Orders may shift between pages while iterating (new orders arrive, old ones change status),
+// so results are deduplicated by prv_code and the stop condition is re-evaluated against
+// the latest TotalCount on every page. Orders are requested oldest completion first.
+// At most maxPages pages are requested per call: when the limit cuts the walk short,
+// the orders fetched so far are returned together with an errs.Truncated error.
+// If completedAfter is set, only orders completed at or after it are requested.
+func (c *Client) FetchCompletedOrders(ctx context.Context, provider *orderModel.Provider, completedAfter *time.Time) ([]*orderModel.Order, error) {
 	endpoint := fmt.Sprintf("%s/ord", c.baseURL)
 
-	repObj := &FetchCompletedOrdersRepSt{}
-
-	//creationTsGte := time.Now().Add(-time.Hour).Format(time.RFC3339)
-
-	statusOk, respBody, err := c.sendRequest(
-		ctx,
-		http.MethodGet,
-		endpoint,
-		nil,
-		url.Values{
-			"prv_id":    {"kaspi"},
-			"page_size": {"100"},
-			//"creation_ts_gte": {creationTsGte},
-			"status": {"COMPLETED"},
-		},
-		nil,
-		&repObj,
-		nil)
-	if err != nil {
-		slog.Error("FetchCompletedOrders", "error", fmt.Errorf("failed to send request: %w", err))
-		return nil, err
+	pageSize := provider.PageSize
+	if pageSize <= 0 {
+		pageSize = c.pageSize
 	}
-	if !statusOk {
-		slog.Error("FetchCompletedOrders", "statusOk", statusOk, "body", string(respBody))
-		return nil, errs.BadStatusCode
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
 	}
 
-	result := make([]*orderModel.Order, 0, len(repObj.Results))
-	for _, v := range repObj.Results {
-		result = append(result, &orderModel.Order{
-			ExternalOrderID: v.PrvCode,
-			UserPhone:       v.Customer.CellPhone,
-			UserName:        v.Customer.FirstName,
-		})
+	status := provider.Status
+	if status == "" {
+		status = cns.OrderStatusCompleted
+	}
+
+	seen := make(map[string]struct{})
+	result := make([]*orderModel.Order, 0, pageSize)
+
+	for page := 1; ; page++ {
+		if c.maxPages > 0 && page > c.maxPages {
+			slog.Warn("FetchCompletedOrders: page limit reached", "provider", provider.ID, "maxPages", c.maxPages, "fetched", len(result))
+			return result, fmt.Errorf("%w: page limit %d reached for provider %s", errs.Truncated, c.maxPages, provider.ID)
+		}
+
+		repObj := &FetchCompletedOrdersRepSt{}
+
+		params := url.Values{
+			"prv_id":    {provider.ID},
+			"page":      {strconv.Itoa(page)},
+			"page_size": {strconv.Itoa(pageSize)},
+			"status":    {status},
+			"ordering":  {"completion_ts"},
+		}
+		if completedAfter != nil {
+			params.Set("completion_ts_gte", completedAfter.Format(time.RFC3339))
+		}
+
+		statusOk, respBody, err := c.sendRequest(
+			ctx,
+			http.MethodGet,
+			endpoint,
+			nil,
+			params,
+			nil,
+			&repObj)
+		if err != nil {
+			slog.Error("FetchCompletedOrders", "provider", provider.ID, "page", page, "error", fmt.Errorf("failed to send request: %w", err))
+			return nil, err
+		}
+		if !statusOk {
+			slog.Error("FetchCompletedOrders", "provider", provider.ID, "page", page, "statusOk", statusOk, "body", string(respBody))
+			return nil, errs.BadStatusCode
+		}
+
+		if err = repObj.Validate(); err != nil {
+			slog.Error("FetchCompletedOrders: invalid page", "provider", provider.ID, "page", page, "error", err)
+			return nil, err
+		}
+
+		for i := range repObj.Results {
+			v := &repObj.Results[i]
+
+			if err = v.Validate(); err != nil {
+				slog.Error("FetchCompletedOrders: rejected order", "provider", provider.ID, "page", page, "index", i, "error", err)
+				continue
+			}
+			if v.Customer.CellPhone == "" {
+				slog.Warn("FetchCompletedOrders: order without customer phone", "provider", provider.ID, "orderID", v.PrvCode)
+			}
+
+			if _, ok := seen[v.PrvCode]; ok {
+				continue
+			}
+			seen[v.PrvCode] = struct{}{}
+
+			result = append(result, v.ToOrder(provider.ID))
+		}
+
+		if len(repObj.Results) == 0 || page*pageSize >= repObj.TotalCount {
+			break
+		}
 	}
 
 	return result, nil
 }
 
-func (c *Client) FetchProductCodes(ctx context.Context, orderID string) ([]string, error) {
+// FetchOrderItems returns the items of the provider order. mb-broker only exposes
+// the product codes of an order, so every item carries its product code with quantity 1;
+// the name, price, category and SKU are filled in from the product catalog.
+func (c *Client) FetchOrderItems(ctx context.Context, providerID, orderID string) ([]*orderDetailModel.OrderDetail, error) {
 	endpoint := fmt.Sprintf("%s/ord/product_codes", c.baseURL)
 
 	var repObj []string
@@ -83,20 +157,39 @@ func (c *Client) FetchProductCodes(ctx context.Context, orderID string) ([]strin
 		&FetchProductCodesReqSt{
 			PrvCode: orderID,
 		},
-		&repObj,
-		nil)
+		&repObj)
 	if err != nil {
-		slog.Error("FetchProductCodesByOrder", "error", fmt.Errorf("failed to send request: %w", err))
+		slog.Error("FetchOrderItems", "provider", providerID, "error", fmt.Errorf("failed to send request: %w", err))
 		return nil, err
 	}
 	if !statusOk {
-		slog.Error("FetchProductCodesByOrder", "statusOk", statusOk, "body", string(respBody))
+		slog.Error("FetchOrderItems", "provider", providerID, "statusOk", statusOk, "body", string(respBody))
 		return nil, errs.BadStatusCode
 	}
 
-	return repObj, nil
+	result := make([]*orderDetailModel.OrderDetail, 0, len(repObj))
+	for i, productCode := range repObj {
+		if productCode == "" {
+			slog.Error("FetchOrderItems: rejected item", "orderID", orderID, "index", i, "error", fmt.Errorf("%w: empty product code", errs.InvalidResponse))
+			continue
+		}
+
+		result = append(result, &orderDetailModel.OrderDetail{
+			ProductCode: productCode,
+			Quantity:    1,
+		})
+	}
+
+	return result, nil
 }
 
+// sendRequest sends the request, retrying transport errors, 429 and 5xx responses
+// according to the client retry policy. Other 4xx responses are returned immediately.
+// Every attempt waits for the rate limiter and goes through the circuit breaker,
+// so retries stop as soon as it opens.
+// A 401 response drops the cached token and is retried once with a fresh one.
+// A token that cannot be obtained fails the request with errs.TokenUnavailable
+// right away: the token endpoint is neither retried here nor counted by the breaker.
 func (c *Client) sendRequest(
 	ctx context.Context,
 	method string,
@@ -104,15 +197,98 @@ func (c *Client) sendRequest(
 	header http.Header,
 	params url.Values,
 	reqObj any,
-	repObj any,
-	errRepObj any) (bool, []byte, error) {
+	repObj any) (bool, []byte, error) {
 
-	var reqBody io.Reader
+	var reqJson []byte
 	if reqObj != nil {
-		reqJson, err := json.Marshal(reqObj)
+		var err error
+		reqJson, err = json.Marshal(reqObj)
 		if err != nil {
 			return false, nil, fmt.Errorf("json.Marshal: %w", err)
 		}
+	}
+
+	var deadline time.Time
+	if c.retry.Budget > 0 {
+		deadline = time.Now().Add(c.retry.Budget)
+	}
+
+	reauthorized := false
+
+	for attempt := 1; ; attempt++ {
+		token, err := c.tokens.Token(ctx)
+		if err != nil {
+			return false, nil, fmt.Errorf("%w: %w", errs.TokenUnavailable, err)
+		}
+
+		if err = c.limiter.wait(ctx); err != nil {
+			return false, nil, err
+		}
+
+		if err = c.breaker.Allow(); err != nil {
+			return false, nil, err
+		}
+
+		statusOk, repBody, err := c.doRequest(ctx, method, url, header, params, token, reqJson, repObj)
+		if !reauthorized && isUnauthorized(err) {
+			c.breaker.Success()
+			c.tokens.Invalidate()
+			reauthorized = true
+			attempt--
+
+			slog.Warn("mb-broker rejected token, retrying with a fresh one", "endpoint", url, "error", err)
+			continue
+		}
+
+		switch {
+		case err == nil:
+			c.breaker.Success()
+		case ctx.Err() != nil:
+			c.breaker.Release()
+		case isRetryable(ctx, err):
+			c.breaker.Failure()
+		case isAnswered(err):
+			// the service answered, the request itself was wrong
+			c.breaker.Success()
+		default:
+			// the request never reached the service
+			c.breaker.Release()
+		}
+
+		if err == nil || attempt > c.retry.MaxRetries || !isRetryable(ctx, err) {
+			return statusOk, repBody, err
+		}
+
+		wait := c.retry.delay(attempt, err)
+		if !deadline.IsZero() && time.Now().Add(wait).After(deadline) {
+			slog.Warn("mb-broker retry budget exhausted", "endpoint", url, "attempt", attempt, "error", err)
+			return statusOk, repBody, err
+		}
+
+		slog.Warn("mb-broker request failed, retrying", "endpoint", url, "attempt", attempt, "wait", wait, "error", err)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return false, nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (c *Client) doRequest(
+	ctx context.Context,
+	method string,
+	url string,
+	header http.Header,
+	params url.Values,
+	token string,
+	reqJson []byte,
+	repObj any) (bool, []byte, error) {
+
+	var reqBody io.Reader
+	if reqJson != nil {
 		reqBody = bytes.NewReader(reqJson)
 	}
 
@@ -122,13 +298,14 @@ func (c *Client) sendRequest(
 	}
 
 	if header != nil {
-		req.Header = header
+		req.Header = header.Clone()
 	}
 
-	if reqObj != nil {
+	if reqJson != nil {
 		req.Header.Add("Content-Type", "application/json")
 	}
-	req.Header.Add("Authorization", "Bearer "+c.token)
+
+	req.Header.Add("Authorization", "Bearer "+token)
 
 	if params != nil {
 		req.URL.RawQuery = params.Encode()
@@ -136,25 +313,22 @@ func (c *Client) sendRequest(
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		return false, nil, fmt.Errorf("client.Do: %w", err)
+		return false, nil, fmt.Errorf("%w: client.Do: %w", errs.Transient, err)
 	}
 	defer resp.Body.Close()
 
 	repBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return false, nil, fmt.Errorf("resp.Body.ReadAll: %w", err)
+		return false, nil, fmt.Errorf("%w: resp.Body.ReadAll: %w", errs.Transient, err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		if errRepObj != nil && len(repBody) > 0 {
-			_ = json.Unmarshal(repBody, errRepObj)
-		}
-		return false, nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return false, repBody, newAPIError(resp, repBody)
 	}
 
 	if repObj != nil {
 		if err = json.Unmarshal(repBody, repObj); err != nil {
-			return false, nil, fmt.Errorf("json.Unmarshal: %w", err)
+			return false, nil, fmt.Errorf("%w: json.Unmarshal: %w", errs.InvalidResponse, err)
 		}
 	}
 
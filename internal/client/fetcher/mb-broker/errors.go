@@ -0,0 +1,77 @@
+package mb_broker
+
+import (
+	"encoding/json"
+	"fmt"
+	"mb-feedback/internal/errs"
+	"net/http"
+	"time"
+)
+
+// ErrorRepSt is the error payload returned by mb-broker with non-200 responses.
+type ErrorRepSt struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	RequestID string `json:"request_id"`
+}
+
+// APIError is a non-200 mb-broker response. It matches errs sentinels with errors.Is:
+// every APIError is errs.BadStatusCode, and depending on the status code also
+// errs.InvalidInput, errs.Unauthorized or errs.ObjectNotFound.
+type APIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+	RequestID  string
+
+	retryAfter time.Duration
+}
+
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	result := &APIError{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("X-Request-Id"),
+		retryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+
+	repObj := &ErrorRepSt{}
+	if len(body) > 0 && json.Unmarshal(body, repObj) == nil {
+		result.Code = repObj.Code
+		result.Message = repObj.Message
+		if repObj.RequestID != "" {
+			result.RequestID = repObj.RequestID
+		}
+	}
+
+	return result
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("mb-broker: unexpected status code: %d", e.StatusCode)
+	if e.Code != "" {
+		msg += ", code: " + e.Code
+	}
+	if e.Message != "" {
+		msg += ", message: " + e.Message
+	}
+	if e.RequestID != "" {
+		msg += ", request_id: " + e.RequestID
+	}
+
+	return msg
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case errs.BadStatusCode:
+		return true
+	case errs.InvalidInput:
+		return e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusUnprocessableEntity
+	case errs.Unauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case errs.ObjectNotFound:
+		return e.StatusCode == http.StatusNotFound
+	}
+
+	return false
+}
@@ -1,5 +1,10 @@
 package mb_broker
 
+import (
+	orderModel "mb-feedback/internal/domain/order/model"
+	"time"
+)
+
 type FetchCompletedOrdersRepSt struct {
 	Page       int     `json:"page"`
 	PageSize   int     `json:"page_size"`
@@ -8,16 +13,42 @@ type FetchCompletedOrdersRepSt struct {
 }
 
 type OrdSt struct {
-	PrvCode  string        `json:"prv_code"`
-	Customer OrdCustomerSt `json:"customer"`
+	PrvCode      string        `json:"prv_code"`
+	Status       string        `json:"status"`
+	CompletionTs time.Time     `json:"completion_ts"`
+	Customer     OrdCustomerSt `json:"customer"`
+}
+
+// ToOrder converts the mb-broker order of the provider to the domain model.
+func (o *OrdSt) ToOrder(providerID string) *orderModel.Order {
+	return &orderModel.Order{
+		Provider:        providerID,
+		ExternalOrderID: o.PrvCode,
+		UserPhone:       o.Customer.CellPhone,
+		UserName:        o.Customer.FirstName,
+		UserLastName:    o.Customer.LastName,
+		UserEmail:       o.Customer.Email,
+		Status:          o.Status,
+		CompletedAt:     o.CompletionTs,
+	}
 }
 
 type OrdCustomerSt struct {
 	CellPhone string `json:"cell_phone"`
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
+	Email     string `json:"email"`
 }
 
 type FetchProductCodesReqSt struct {
 	PrvCode string `json:"prv_code"`
 }
+
+const EventOrderCompleted = "order.completed"
+
+// OrderEventSt is the payload mb-broker pushes to the orders webhook.
+type OrderEventSt struct {
+	Event string `json:"event"`
+	PrvID string `json:"prv_id"`
+	Order OrdSt  `json:"order"`
+}
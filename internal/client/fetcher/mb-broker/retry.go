@@ -0,0 +1,93 @@
+package mb_broker
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"mb-feedback/internal/errs"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy configures how failed mb-broker requests are retried.
+type RetryPolicy struct {
+	// MaxRetries is the number of retries after the first attempt, 0 disables retries.
+	MaxRetries int
+	// BaseDelay is the delay before the first retry, doubled on every next one.
+	BaseDelay time.Duration
+	// MaxDelay caps the exponential backoff delay.
+	MaxDelay time.Duration
+	// Budget caps the total time spent on one request including all retries, 0 means no limit.
+	Budget time.Duration
+}
+
+// delay returns how long to wait before the retry following the given attempt.
+// Retry-After sent by the server takes precedence over the backoff.
+func (p RetryPolicy) delay(attempt int, err error) time.Duration {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.retryAfter > 0 {
+		return apiErr.retryAfter
+	}
+
+	backoff := p.BaseDelay << (attempt - 1)
+	if backoff <= 0 || (p.MaxDelay > 0 && backoff > p.MaxDelay) {
+		backoff = p.MaxDelay
+	}
+	if backoff <= 0 {
+		return 0
+	}
+
+	// equal jitter: keep half of the backoff, randomize the rest
+	half := backoff / 2
+	return half + rand.N(half+1)
+}
+
+// isRetryable reports whether a failed request may succeed if sent again:
+// 429 and 5xx responses, and transport errors marked with errs.Transient.
+// Errors building the request or decoding a 200 response are not retried.
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	return errors.Is(err, errs.Transient)
+}
+
+// isAnswered reports whether the error came with a response from mb-broker.
+func isAnswered(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) || errors.Is(err, errs.InvalidResponse)
+}
+
+// parseRetryAfter parses the Retry-After header given either in seconds or as an HTTP date.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+
+	return 0
+}
+
+func isUnauthorized(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized
+}
@@ -0,0 +1,25 @@
+package mb_broker
+
+import (
+	"fmt"
+	"mb-feedback/internal/errs"
+)
+
+// Validate checks the page metadata returned by mb-broker.
+func (r *FetchCompletedOrdersRepSt) Validate() error {
+	if r.TotalCount < 0 || r.PageSize < 0 || r.Page < 0 {
+		return fmt.Errorf("%w: bad page metadata: page=%d page_size=%d total_count=%d", errs.InvalidResponse, r.Page, r.PageSize, r.TotalCount)
+	}
+
+	return nil
+}
+
+// Validate checks the order returned by mb-broker. A missing customer phone is
+// not an error here, callers flag it and the order service rejects it on import.
+func (o *OrdSt) Validate() error {
+	if o.PrvCode == "" {
+		return fmt.Errorf("%w: empty prv_code", errs.InvalidResponse)
+	}
+
+	return nil
+}
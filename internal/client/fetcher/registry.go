@@ -0,0 +1,54 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	orderModel "mb-feedback/internal/domain/order/model"
+	orderDetailModel "mb-feedback/internal/domain/order_detail/model"
+	"mb-feedback/internal/errs"
+	"time"
+)
+
+// Registry routes every call to the source registered for the provider,
+// so it can be used as a single Fetcher for all configured providers.
+type Registry struct {
+	sources map[string]Fetcher
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		sources: make(map[string]Fetcher),
+	}
+}
+
+// Register sets the source orders of the provider are fetched from.
+func (r *Registry) Register(providerID string, source Fetcher) {
+	r.sources[providerID] = source
+}
+
+func (r *Registry) FetchCompletedOrders(ctx context.Context, provider *orderModel.Provider, completedAfter *time.Time) ([]*orderModel.Order, error) {
+	source, err := r.source(provider.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return source.FetchCompletedOrders(ctx, provider, completedAfter)
+}
+
+func (r *Registry) FetchOrderItems(ctx context.Context, providerID, orderID string) ([]*orderDetailModel.OrderDetail, error) {
+	source, err := r.source(providerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return source.FetchOrderItems(ctx, providerID, orderID)
+}
+
+func (r *Registry) source(providerID string) (Fetcher, error) {
+	source, ok := r.sources[providerID]
+	if !ok {
+		return nil, fmt.Errorf("%w: no order source for provider %s", errs.InvalidInput, providerID)
+	}
+
+	return source, nil
+}
@@ -0,0 +1,86 @@
+// Package static implements an order source serving a fixed set of test orders.
+package static
+
+import (
+	"context"
+	"fmt"
+	orderModel "mb-feedback/internal/domain/order/model"
+	orderDetailModel "mb-feedback/internal/domain/order_detail/model"
+	"mb-feedback/internal/errs"
+	"time"
+)
+
+type orderSt struct {
+	externalOrderID string
+	phone           string
+	name            string
+	items           []*orderDetailModel.OrderDetail
+}
+
+var orders = []*orderSt{
+	{
+		externalOrderID: "static-1",
+		phone:           "7000000001",
+		name:            "Тест",
+		items: []*orderDetailModel.OrderDetail{
+			{ProductCode: "static-product-1", ProductName: "Test product 1", Quantity: 1, UnitPrice: 1000, Category: "electronics"},
+		},
+	},
+	{
+		externalOrderID: "static-2",
+		phone:           "7000000002",
+		name:            "Test",
+		items: []*orderDetailModel.OrderDetail{
+			{ProductCode: "static-product-2", ProductName: "Test product 2", Quantity: 2, UnitPrice: 2500, Category: "clothing"},
+			{ProductCode: "static-product-3", ProductName: "Test product 3", Quantity: 1, UnitPrice: 500, Category: "clothing"},
+		},
+	},
+}
+
+type Source struct {
+	completedAt time.Time
+}
+
+// New creates the source. All its orders are reported as completed at the creation time.
+func New() *Source {
+	return &Source{
+		completedAt: time.Now(),
+	}
+}
+
+func (s *Source) FetchCompletedOrders(_ context.Context, provider *orderModel.Provider, completedAfter *time.Time) ([]*orderModel.Order, error) {
+	if completedAfter != nil && s.completedAt.Before(*completedAfter) {
+		return nil, nil
+	}
+
+	result := make([]*orderModel.Order, 0, len(orders))
+	for _, order := range orders {
+		result = append(result, &orderModel.Order{
+			Provider:        provider.ID,
+			ExternalOrderID: order.externalOrderID,
+			UserPhone:       order.phone,
+			UserName:        order.name,
+			CompletedAt:     s.completedAt,
+		})
+	}
+
+	return result, nil
+}
+
+func (s *Source) FetchOrderItems(_ context.Context, _, orderID string) ([]*orderDetailModel.OrderDetail, error) {
+	for _, order := range orders {
+		if order.externalOrderID != orderID {
+			continue
+		}
+
+		result := make([]*orderDetailModel.OrderDetail, 0, len(order.items))
+		for _, item := range order.items {
+			copied := *item
+			result = append(result, &copied)
+		}
+
+		return result, nil
+	}
+
+	return nil, fmt.Errorf("order %s: %w", orderID, errs.ObjectNotFound)
+}
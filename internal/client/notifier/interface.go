@@ -1,7 +1,43 @@
 package notifier
 
-import "context"
+import (
+	"context"
+	"errors"
+	"mb-feedback/internal/errs"
+)
+
+// Result describes the provider response to a sent notification.
+// It is returned for failed sends too whenever the provider answered.
+type Result struct {
+	MessageID    string
+	ResponseCode int
+	RawResponse  string
+	// Sandboxed means the message was stored in sandbox_message instead of being sent,
+	// MessageID is then the sandbox message ID and SandboxReason tells why it was not sent
+	Sandboxed     bool
+	SandboxReason string
+}
+
+// Message is a feedback request for one ordered product.
+type Message struct {
+	OrderID     string
+	UserPhone   string
+	UserName    string
+	ProductCode string
+	// TemplateID overrides the notifier default template when set
+	TemplateID string
+	// TextParams are the template text params, empty means the notifier defaults
+	TextParams map[string]string
+}
 
 type Notifier interface {
-	SendNotification(ctx context.Context, orderID, userPhone, userName, productCode string) error
+	SendNotification(ctx context.Context, msg *Message) (*Result, error)
+}
+
+// IsTransient reports whether a failed send may succeed when retried later,
+// e.g. after network errors, throttling, provider outages or an open circuit breaker.
+func IsTransient(err error) bool {
+	return errors.Is(err, errs.Transient) ||
+		errors.Is(err, errs.CircuitOpen) ||
+		errors.Is(err, context.DeadlineExceeded)
 }
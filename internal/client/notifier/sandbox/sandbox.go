@@ -0,0 +1,108 @@
+package sandbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"mb-feedback/internal/client/notifier"
+	"mb-feedback/internal/client/notifier/voximplant"
+	sandboxMessageModel "mb-feedback/internal/domain/sandbox_message/model"
+	"net/url"
+)
+
+const (
+	redacted = "REDACTED"
+
+	reasonSandbox        = "sandbox"
+	reasonNotAllowlisted = "not allowlisted"
+)
+
+type RendererI interface {
+	RenderRequest(msg *notifier.Message) (*voximplant.Request, error)
+}
+
+type SandboxMessageServiceI interface {
+	Create(ctx context.Context, obj *sandboxMessageModel.Edit) (string, error)
+}
+
+// Notifier renders the Voximplant request of every message, logs it and stores it
+// instead of sending. Messages to allowlisted phone numbers are sent through next.
+type Notifier struct {
+	renderer              RendererI
+	sandboxMessageService SandboxMessageServiceI
+
+	next      notifier.Notifier
+	allowlist map[string]struct{}
+}
+
+// New creates the notifier. With a nil next nothing is sent at all, otherwise
+// messages to the allowlist phone numbers, in the +77XXXXXXXXX format, are sent through it.
+func New(renderer RendererI, sandboxMessageService SandboxMessageServiceI, next notifier.Notifier, allowlist []string) *Notifier {
+	result := &Notifier{
+		renderer:              renderer,
+		sandboxMessageService: sandboxMessageService,
+		next:                  next,
+		allowlist:             make(map[string]struct{}, len(allowlist)),
+	}
+	for _, phone := range allowlist {
+		result.allowlist[phone] = struct{}{}
+	}
+
+	return result
+}
+
+// SendNotification sends the message through the next notifier if the phone number is allowlisted,
+// otherwise it stores the rendered request and returns a sandboxed result.
+func (n *Notifier) SendNotification(ctx context.Context, msg *notifier.Message) (*notifier.Result, error) {
+	reason := reasonSandbox
+	if n.next != nil {
+		if _, ok := n.allowlist[msg.UserPhone]; ok {
+			return n.next.SendNotification(ctx, msg)
+		}
+		reason = reasonNotAllowlisted
+	}
+
+	req, err := n.renderer.RenderRequest(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render request: %w", err)
+	}
+
+	// the token must not end up in logs and the database
+	params := make(url.Values, len(req.Params))
+	for name, values := range req.Params {
+		params[name] = values
+	}
+	params.Set("access_token", redacted)
+	body := params.Encode()
+
+	slog.Info("Sandbox notification",
+		"reason", reason, "orderID", msg.OrderID, "productCode", msg.ProductCode, "phone", msg.UserPhone,
+		"method", req.Method, "url", req.URL, "body", body)
+
+	id, err := n.sandboxMessageService.Create(ctx, &sandboxMessageModel.Edit{
+		PhoneNumber: msg.UserPhone,
+		OrderID:     msg.OrderID,
+		ProductCode: msg.ProductCode,
+		TemplateID:  req.TemplateID,
+		Method:      req.Method,
+		URL:         req.URL,
+		Body:        body,
+		Reason:      reason,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to store sandbox message: %w", err)
+	}
+
+	rawResponse, err := json.Marshal(map[string]any{"sandbox_message_id": id, "reason": reason})
+	if err != nil {
+		return nil, err
+	}
+
+	return &notifier.Result{
+		MessageID:     id,
+		RawResponse:   string(rawResponse),
+		Sandboxed:     true,
+		SandboxReason: reason,
+	}, nil
+}
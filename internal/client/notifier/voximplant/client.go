@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"mb-feedback/internal/client/breaker"
+	"mb-feedback/internal/client/notifier"
 	"mb-feedback/internal/errs"
 	"net/http"
 	"net/url"
@@ -19,72 +21,145 @@ type Client struct {
 	domainName string
 	templateID string
 	channelID  string
+	breaker    *breaker.Breaker
 }
 
-type TextParamValues struct {
-	Name2 string `json:"name2"`
-}
-
-func New(baseURL, token, domainName, templateID, channelID string) *Client {
+// New creates the client. transport is used for all requests, nil means http.DefaultTransport.
+func New(baseURL, token, domainName, templateID, channelID string, breaker *breaker.Breaker, transport http.RoundTripper) *Client {
 	return &Client{
-		client:     &http.Client{},
+		client:     &http.Client{Transport: transport},
 		baseURL:    baseURL,
 		token:      token,
 		domainName: domainName,
 		templateID: templateID,
 		channelID:  channelID,
+		breaker:    breaker,
 	}
 }
 
-func (c *Client) SendNotification(ctx context.Context, orderID, userPhone, userName, productCode string) error {
-	endpoint := fmt.Sprintf("%s/api/v3/botService/sendTemplateMessage", c.baseURL)
+// Request is a rendered sendTemplateMessage request.
+type Request struct {
+	Method     string
+	URL        string
+	TemplateID string
+	// Params are the form-encoded body params, including the access token
+	Params url.Values
+}
 
-	buttonUrlParam := fmt.Sprintf("orderCode=%s&productCode=%s&rating=5", orderID, productCode)
+// RenderRequest builds the sendTemplateMessage request for the message without sending it.
+// The message template and text params take precedence over the client defaults.
+func (c *Client) RenderRequest(msg *notifier.Message) (*Request, error) {
+	buttonUrlParam := fmt.Sprintf("orderCode=%s&productCode=%s&rating=5", msg.OrderID, msg.ProductCode)
 
-	data, err := json.MarshalIndent(&TextParamValues{
-		Name2: userName,
-	}, "", "  ")
+	templateID := c.templateID
+	if msg.TemplateID != "" {
+		templateID = msg.TemplateID
+	}
+
+	var textParams any = &TextParamValues{
+		Name2: msg.UserName,
+	}
+	if len(msg.TextParams) > 0 {
+		textParams = msg.TextParams
+	}
+
+	data, err := json.MarshalIndent(textParams, "", "  ")
 	if err != nil {
 		slog.Error("Marshal text_param values error:", "error", err)
-		return err
+		return nil, err
 	}
 
-	params := url.Values{
-		"domain":                 {c.domainName},
-		"client_id":              {userPhone},
-		"message_template_id":    {c.templateID},
-		"channel_id":             {c.channelID},
-		"access_token":           {c.token},
-		"header_param_value":     {userName},
-		"button_url_param_value": {buttonUrlParam},
-		"text_param_values":      {string(data)},
+	return &Request{
+		Method:     http.MethodPost,
+		URL:        fmt.Sprintf("%s/api/v3/botService/sendTemplateMessage", c.baseURL),
+		TemplateID: templateID,
+		Params: url.Values{
+			"domain":                 {c.domainName},
+			"client_id":              {msg.UserPhone},
+			"message_template_id":    {templateID},
+			"channel_id":             {c.channelID},
+			"access_token":           {c.token},
+			"header_param_value":     {msg.UserName},
+			"button_url_param_value": {buttonUrlParam},
+			"text_param_values":      {string(data)},
+		},
+	}, nil
+}
+
+// SendNotification sends the feedback request template to the user.
+// The result carries the Voximplant message ID and response whenever Voximplant answered.
+func (c *Client) SendNotification(ctx context.Context, msg *notifier.Message) (*notifier.Result, error) {
+	rendered, err := c.RenderRequest(msg)
+	if err != nil {
+		return nil, err
 	}
+	templateID := rendered.TemplateID
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewBufferString(params.Encode()))
+	req, err := http.NewRequestWithContext(ctx, rendered.Method, rendered.URL, bytes.NewBufferString(rendered.Params.Encode()))
 	if err != nil {
 		slog.Error("NewRequestWithContext error:", "error", err)
-		return fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
+	if err = c.breaker.Allow(); err != nil {
+		slog.Error("Voximplant is unavailable:", "error", err)
+		return nil, err
+	}
+
 	res, err := c.client.Do(req)
 	if err != nil {
+		if ctx.Err() != nil {
+			c.breaker.Release()
+		} else {
+			c.breaker.Failure()
+		}
 		slog.Error("Do request error:", "error", err)
-		return fmt.Errorf("failed to send request: %w", err)
+		return nil, fmt.Errorf("%w: failed to send request: %w", errs.Transient, err)
 	}
 	defer res.Body.Close()
 
+	if res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= http.StatusInternalServerError {
+		c.breaker.Failure()
+	} else {
+		c.breaker.Success()
+	}
+
 	body, err := io.ReadAll(res.Body)
 	if err != nil {
 		slog.Error("Read response body error:", "error", err)
-		return fmt.Errorf("failed to read response body: %w", err)
+		return nil, fmt.Errorf("%w: failed to read response body: %w", errs.Transient, err)
+	}
+
+	result := &notifier.Result{
+		ResponseCode: res.StatusCode,
+		RawResponse:  string(body),
+	}
+
+	if res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= http.StatusInternalServerError {
+		slog.Error("Voximplant is unavailable", "statusCode", res.StatusCode, "respBody", string(body))
+		return result, fmt.Errorf("%w: %w: status code %d", errs.Transient, errs.BadStatusCode, res.StatusCode)
 	}
 
 	if res.StatusCode != http.StatusOK {
-		slog.Error("unexpected status code: %d, response: %s", "statusCode", res.StatusCode, "respBody", string(body))
-		return errs.BadStatusCode
+		slog.Error("Unexpected status code", "statusCode", res.StatusCode, "respBody", string(body))
+		return result, fmt.Errorf("%w: status code %d", errs.BadStatusCode, res.StatusCode)
 	}
 
-	fmt.Printf("Notification sent successfully: %s\n", string(body))
-	return nil
+	repObj := &SendTemplateMessageRepSt{}
+	if err = json.Unmarshal(body, repObj); err != nil {
+		slog.Error("Unmarshal response error:", "error", err, "respBody", string(body))
+		return result, fmt.Errorf("%w: %w", errs.InvalidResponse, err)
+	}
+
+	if repObj.Error != nil {
+		slog.Error("Voximplant rejected notification", "code", repObj.Error.Code, "msg", repObj.Error.Msg)
+		return result, fmt.Errorf("%w: voximplant error %d: %s", errs.BadStatusCode, repObj.Error.Code, repObj.Error.Msg)
+	}
+
+	result.MessageID = repObj.messageID()
+
+	slog.Info("Notification sent", "orderID", msg.OrderID, "productCode", msg.ProductCode, "templateID", templateID, "messageID", result.MessageID)
+
+	return result, nil
 }
@@ -0,0 +1,80 @@
+package voximplant
+
+import (
+	"encoding/json"
+	"mb-feedback/internal/cns"
+	"strings"
+	"time"
+)
+
+type TextParamValues struct {
+	Name2 string `json:"name2"`
+}
+
+// SendTemplateMessageRepSt is the sendTemplateMessage response. The message ID is
+// returned either at the top level or inside result depending on the API version.
+type SendTemplateMessageRepSt struct {
+	MessageID string          `json:"message_id"`
+	Result    json.RawMessage `json:"result"`
+	Error     *ErrorSt        `json:"error"`
+}
+
+type ErrorSt struct {
+	Code int    `json:"code"`
+	Msg  string `json:"msg"`
+}
+
+type sendTemplateMessageResultSt struct {
+	MessageID string `json:"message_id"`
+}
+
+// messageID returns the provider message ID from whichever field carries it.
+func (r *SendTemplateMessageRepSt) messageID() string {
+	if r.MessageID != "" {
+		return r.MessageID
+	}
+
+	result := &sendTemplateMessageResultSt{}
+	if len(r.Result) > 0 && json.Unmarshal(r.Result, result) == nil {
+		return result.MessageID
+	}
+
+	return ""
+}
+
+// StatusCallbackSt is the message status callback Voximplant sends to the service.
+type StatusCallbackSt struct {
+	Events []MessageStatusEventSt `json:"events"`
+}
+
+type MessageStatusEventSt struct {
+	MessageID string `json:"message_id"`
+	// Status is sent, delivered, read, undelivered or failed
+	Status string `json:"status"`
+	// Timestamp is the unix time of the status change
+	Timestamp int64 `json:"timestamp"`
+}
+
+// DeliveryStatus maps the event status to the notification status,
+// it returns "" for statuses the service does not track.
+func (e *MessageStatusEventSt) DeliveryStatus() string {
+	switch strings.ToLower(e.Status) {
+	case "delivered":
+		return cns.StatusDelivered
+	case "read":
+		return cns.StatusRead
+	case "undelivered", "failed", "rejected", "expired":
+		return cns.StatusUndelivered
+	}
+
+	return ""
+}
+
+// Time returns when the status changed, falling back to the current time.
+func (e *MessageStatusEventSt) Time() time.Time {
+	if e.Timestamp <= 0 {
+		return time.Now()
+	}
+
+	return time.Unix(e.Timestamp, 0)
+}
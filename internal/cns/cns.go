@@ -1,6 +1,67 @@
 package cns
 
+// notification statuses
 const (
-	StatusSent   = "SENT"
-	StatusFailed = "FAILED"
+	StatusSent    = "SENT"
+	StatusFailed  = "FAILED"
+	StatusSkipped = "SKIPPED"
+	// StatusDeferred waits for the customer send window to open
+	StatusDeferred = "DEFERRED"
+	// StatusGaveUp is terminal: the send failed permanently or ran out of attempts
+	StatusGaveUp = "GAVE_UP"
+	// StatusSuppressed is terminal: the customer frequency cap was reached
+	StatusSuppressed = "SUPPRESSED"
+	// StatusOptedOut is terminal: the customer phone is on the suppression list
+	StatusOptedOut = "OPTED_OUT"
+	// StatusSandboxed is terminal in the sandbox and allowlist notifier modes: the message
+	// was stored instead of being sent, in the live mode the detail is notified again
+	StatusSandboxed = "SANDBOXED"
+
+	// delivery statuses reported by Voximplant callbacks
+	StatusDelivered   = "DELIVERED"
+	StatusRead        = "READ"
+	StatusUndelivered = "UNDELIVERED"
+)
+
+// order statuses
+const (
+	OrderStatusCompleted = "COMPLETED"
+	OrderStatusReturned  = "RETURNED"
+	OrderStatusCancelled = "CANCELLED"
+)
+
+// order sources
+const (
+	SourceMbBroker = "mb-broker"
+	SourceFile     = "file"
+	SourceStatic   = "static"
+)
+
+// mb-broker auth modes
+const (
+	AuthModeStatic = "static"
+	AuthModeOAuth2 = "oauth2"
+)
+
+// notifier modes
+const (
+	// NotifierModeLive sends every message through Voximplant
+	NotifierModeLive = "live"
+	// NotifierModeSandbox stores rendered Voximplant requests instead of sending them
+	NotifierModeSandbox = "sandbox"
+	// NotifierModeAllowlist sends messages to allowlisted phones only, the rest are stored like in sandbox mode
+	NotifierModeAllowlist = "allowlist"
+)
+
+// suppression list sources
+const (
+	SuppressionSourceAPI = "api"
+	SuppressionSourceCSV = "csv"
+)
+
+// order and suppression import row statuses
+const (
+	ImportRowAccepted  = "ACCEPTED"
+	ImportRowDuplicate = "DUPLICATE"
+	ImportRowRejected  = "REJECTED"
 )
@@ -1,12 +1,56 @@
 package conf
 
-import "github.com/caarlos0/env/v9"
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/caarlos0/env/v9"
+	"time"
+)
 
 var Conf = struct {
 	HTTPListen string `env:"HTTP_LISTEN"`
 
-	MbBrokerURL   string `env:"mb_broker_url"`
-	MbBrokerToken string `env:"mb_broker_token"`
+	MbBrokerURL      string `env:"mb_broker_url"`
+	MbBrokerToken    string `env:"mb_broker_token"`
+	MbBrokerPageSize int    `env:"mb_broker_page_size" envDefault:"100"`
+	MbBrokerMaxPages int    `env:"mb_broker_max_pages" envDefault:"100"`
+
+	// MbBrokerAuthMode is static (mb_broker_token is sent as is) or oauth2
+	// (tokens are fetched from mb_broker_token_url with the client-credentials grant)
+	MbBrokerAuthMode           string        `env:"mb_broker_auth_mode" envDefault:"static"`
+	MbBrokerTokenURL           string        `env:"mb_broker_token_url"`
+	MbBrokerClientID           string        `env:"mb_broker_client_id"`
+	MbBrokerClientSecret       string        `env:"mb_broker_client_secret"`
+	MbBrokerScope              string        `env:"mb_broker_scope"`
+	MbBrokerTokenRefreshBefore time.Duration `env:"mb_broker_token_refresh_before" envDefault:"1m"`
+
+	MbBrokerRetryMax       int           `env:"mb_broker_retry_max" envDefault:"3"`
+	MbBrokerRetryBaseDelay time.Duration `env:"mb_broker_retry_base_delay" envDefault:"500ms"`
+	MbBrokerRetryMaxDelay  time.Duration `env:"mb_broker_retry_max_delay" envDefault:"10s"`
+	MbBrokerRetryBudget    time.Duration `env:"mb_broker_retry_budget" envDefault:"30s"`
+
+	// MbBrokerRateLimit caps mb-broker requests per second, retries included
+	MbBrokerRateLimit   float64 `env:"mb_broker_rate_limit" envDefault:"10"`
+	ProductCodesWorkers int     `env:"product_codes_workers" envDefault:"4"`
+
+	// MbBrokerProviders is a JSON list of marketplaces to import orders from,
+	// e.g. [{"id":"kaspi","status":"COMPLETED","page_size":100}].
+	// source selects where orders come from: mb-broker (default), file (with dir) or static.
+	// status_changes lists statuses polled to catch returned or cancelled orders
+	// (RETURNED and CANCELLED by default for mb-broker).
+	MbBrokerProviders Providers `env:"mb_broker_providers" envDefault:"[{\"id\":\"kaspi\",\"status\":\"COMPLETED\"}]"`
+
+	// ImportProviders are providers whose orders only come from imported files, they are
+	// never polled from mb-broker. Imports accept these and MbBrokerProviders. ImportProvider
+	// is the provider of imports that do not name one and must be one of them.
+	ImportProviders []string `env:"import_providers" envSeparator:"," envDefault:"offline"`
+	ImportProvider  string   `env:"import_provider" envDefault:"offline"`
+
+	WebhookSecret    string        `env:"webhook_secret"`
+	WebhookTolerance time.Duration `env:"webhook_tolerance" envDefault:"5m"`
+
+	SyncCursorOverlap  time.Duration `env:"sync_cursor_overlap" envDefault:"10m"`
+	StatusChangeWindow time.Duration `env:"status_change_window" envDefault:"720h"`
 
 	VoximplantURL        string `env:"voximplant_url"`
 	VoximplantToken      string `env:"voximplant_token"`
@@ -14,9 +58,121 @@ var Conf = struct {
 	VoximplantTemplateID string `env:"voximplant_template_id"`
 	VoximplantChannelID  string `env:"voximplant_channel_id"`
 
+	// NotifierMode is live, sandbox or allowlist. In sandbox mode Voximplant requests are
+	// rendered, logged and stored in sandbox_message instead of being sent; in allowlist mode
+	// only messages to NotifierAllowlist phones are sent and the rest are handled like in sandbox mode.
+	// Stored messages are logged as SANDBOXED. NotifierResendSandboxed makes the live mode send
+	// them for real within NotificationLookback, it is meant for an environment that has been
+	// sandboxed on purpose and is off by default
+	NotifierMode            string   `env:"notifier_mode" envDefault:"live"`
+	NotifierAllowlist       []string `env:"notifier_allowlist" envSeparator:","`
+	NotifierResendSandboxed bool     `env:"notifier_resend_sandboxed" envDefault:"false"`
+
+	// NotificationMaxAttempts is the number of send attempts before a failed notification is GAVE_UP
+	NotificationMaxAttempts    int           `env:"notification_max_attempts" envDefault:"5"`
+	NotificationRetryBaseDelay time.Duration `env:"notification_retry_base_delay" envDefault:"5m"`
+	NotificationRetryMaxDelay  time.Duration `env:"notification_retry_max_delay" envDefault:"6h"`
+
+	// SendWindowStart and SendWindowEnd bound the time of day (HH:MM) notifications are sent at
+	// in the customer timezone, equal values disable the window. DefaultTimezone is used when
+	// the customer timezone is unknown and cannot be derived from the phone number.
+	SendWindowStart string `env:"send_window_start" envDefault:"10:00"`
+	SendWindowEnd   string `env:"send_window_end" envDefault:"21:00"`
+	DefaultTimezone string `env:"default_timezone" envDefault:"Asia/Almaty"`
+
+	// FeedbackDelay is how long after the order completion feedback is requested for
+	// categories without a delay rule. NotificationLookback limits how long a detail stays
+	// eligible for its first notification after its scheduled time, older ones are skipped.
+	FeedbackDelay        time.Duration `env:"feedback_delay" envDefault:"72h"`
+	NotificationLookback time.Duration `env:"notification_lookback" envDefault:"24h"`
+
+	// NotificationFrequencyCaps is a JSON list of limits on messages sent to one phone number,
+	// e.g. [{"period":"24h","limit":1}]; an empty list disables the caps
+	NotificationFrequencyCaps FrequencyCaps `env:"notification_frequency_caps" envDefault:"[{\"period\":\"24h\",\"limit\":1},{\"period\":\"720h\",\"limit\":3}]"`
+
+	// DefaultLocale picks message templates for customers whose language is unknown
+	DefaultLocale string `env:"default_locale" envDefault:"ru"`
+
+	// VoximplantCallbackToken authenticates delivery status callbacks, it is sent by
+	// Voximplant in the X-Callback-Token header or the token query parameter
+	VoximplantCallbackToken string `env:"voximplant_callback_token"`
+
+	BreakerFailureThreshold int           `env:"breaker_failure_threshold" envDefault:"5"`
+	BreakerOpenTimeout      time.Duration `env:"breaker_open_timeout" envDefault:"30s"`
+	BreakerHalfOpenProbes   int           `env:"breaker_half_open_probes" envDefault:"1"`
+
+	// HTTPCassetteMode is off, record or replay. In record mode mb-broker and Voximplant
+	// interactions are saved to HTTPCassetteDir with secrets redacted, in replay mode
+	// they are served from there without touching the network.
+	HTTPCassetteMode string `env:"http_cassette_mode" envDefault:"off"`
+	HTTPCassetteDir  string `env:"http_cassette_dir" envDefault:"testdata/cassettes"`
+
 	PgDsn string `env:"pg_dsn"`
 }{}
 
+type ProviderSt struct {
+	ID       string `json:"id"`
+	Status   string `json:"status"`
+	PageSize int    `json:"page_size"`
+	Source   string `json:"source"`
+	Dir      string `json:"dir"`
+
+	StatusChanges []string `json:"status_changes"`
+}
+
+type Providers []ProviderSt
+
+// UnmarshalText parses the providers list from its JSON representation.
+func (p *Providers) UnmarshalText(text []byte) error {
+	var result []ProviderSt
+	if err := json.Unmarshal(text, &result); err != nil {
+		return err
+	}
+
+	for _, provider := range result {
+		if provider.ID == "" {
+			return fmt.Errorf("provider id is required")
+		}
+	}
+
+	*p = result
+	return nil
+}
+
+type FrequencyCapSt struct {
+	Period time.Duration
+	Limit  int
+}
+
+type FrequencyCaps []FrequencyCapSt
+
+// UnmarshalText parses the frequency caps from their JSON representation.
+func (c *FrequencyCaps) UnmarshalText(text []byte) error {
+	var raw []struct {
+		Period string `json:"period"`
+		Limit  int    `json:"limit"`
+	}
+	if err := json.Unmarshal(text, &raw); err != nil {
+		return err
+	}
+
+	result := make(FrequencyCaps, 0, len(raw))
+	for _, v := range raw {
+		period, err := time.ParseDuration(v.Period)
+		if err != nil {
+			return fmt.Errorf("frequency cap period: %w", err)
+		}
+		if period <= 0 || v.Limit < 0 {
+			return fmt.Errorf("frequency cap period must be positive and limit not negative")
+		}
+
+		result = append(result, FrequencyCapSt{Period: period, Limit: v.Limit})
+	}
+
+	*c = result
+	return nil
+}
+
 func init() {
 	if err := env.Parse(&Conf); err != nil {
 		panic(err)
@@ -0,0 +1,45 @@
+package model
+
+import "time"
+
+type Customer struct {
+	ID          string
+	Phone       string
+	FirstName   string
+	LastName    string
+	Email       string
+	Locale      string
+	Timezone    string
+	FirstSeenAt time.Time
+	LastSeenAt  time.Time
+	OrderCount  int
+	CreatedAt   time.Time
+}
+
+type GetPars struct {
+	ID    string
+	Phone string
+}
+
+func (m *GetPars) IsValid() bool {
+	return m.ID != "" || m.Phone != ""
+}
+
+type ListPars struct {
+	ID     *string
+	IDs    *[]string
+	Phone  *string
+	Phones *[]string
+}
+
+// Edit changes the customer. A nil Locale or Timezone keeps the known value,
+// an empty one clears it.
+type Edit struct {
+	Phone     string
+	FirstName *string
+	LastName  *string
+	Email     *string
+	Locale    *string
+	Timezone  *string
+	SeenAt    *time.Time
+}
@@ -0,0 +1,289 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"mb-feedback/internal/domain/customer/model"
+	"mb-feedback/internal/errs"
+	"time"
+)
+
+type Repo struct {
+	Con *pgxpool.Pool
+}
+
+func New(con *pgxpool.Pool) *Repo {
+	return &Repo{
+		con,
+	}
+}
+
+func (r *Repo) Get(ctx context.Context, pars *model.GetPars) (*model.Customer, bool, error) {
+	if !pars.IsValid() {
+		return nil, false, errs.InvalidInput
+	}
+
+	var result model.Customer
+
+	queryBuilder := squirrel.
+		Select("id", "phone", "first_name", "last_name", "COALESCE(email, '')", "COALESCE(locale, '')", "COALESCE(timezone, '')", "first_seen_at", "last_seen_at", "order_count", "created_at").
+		From("customer")
+
+	if len(pars.ID) != 0 {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"id": pars.ID})
+	}
+
+	if len(pars.Phone) != 0 {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"phone": pars.Phone})
+	}
+
+	queryBuilder = queryBuilder.Limit(1)
+
+	sql, args, err := queryBuilder.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return nil, false, err
+	}
+
+	err = r.Con.QueryRow(ctx, sql, args...).Scan(
+		&result.ID, &result.Phone, &result.FirstName, &result.LastName, &result.Email, &result.Locale, &result.Timezone,
+		&result.FirstSeenAt, &result.LastSeenAt, &result.OrderCount, &result.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	return &result, true, nil
+}
+
+func (r *Repo) List(ctx context.Context, pars *model.ListPars) ([]*model.Customer, int64, error) {
+	queryBuilder := squirrel.
+		Select("id", "phone", "first_name", "last_name", "COALESCE(email, '')", "COALESCE(locale, '')", "COALESCE(timezone, '')", "first_seen_at", "last_seen_at", "order_count", "created_at").
+		From("customer")
+
+	if pars.ID != nil {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"id": pars.ID})
+	}
+
+	if pars.IDs != nil {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"id": pars.IDs})
+	}
+
+	if pars.Phone != nil {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"phone": pars.Phone})
+	}
+
+	if pars.Phones != nil {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"phone": pars.Phones})
+	}
+
+	sql, args, err := queryBuilder.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.Con.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var result []*model.Customer
+	for rows.Next() {
+		var data model.Customer
+		err = rows.Scan(
+			&data.ID, &data.Phone, &data.FirstName, &data.LastName, &data.Email, &data.Locale, &data.Timezone,
+			&data.FirstSeenAt, &data.LastSeenAt, &data.OrderCount, &data.CreatedAt)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		result = append(result, &data)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return result, int64(len(result)), nil
+}
+
+// UpsertBatch creates missing customers and refreshes the existing ones.
+// Empty names, emails, locales and timezones never overwrite known values.
+func (r *Repo) UpsertBatch(ctx context.Context, objects []*model.Edit) error {
+	query := squirrel.Insert("customer").
+		Columns("phone", "first_name", "last_name", "email", "locale", "timezone", "first_seen_at", "last_seen_at")
+
+	for _, obj := range objects {
+		seenAt := time.Now()
+		if obj.SeenAt != nil {
+			seenAt = *obj.SeenAt
+		}
+
+		query = query.Values(obj.Phone, valueOrEmpty(obj.FirstName), valueOrEmpty(obj.LastName), obj.Email,
+			squirrel.Expr("NULLIF(?, '')", valueOrEmpty(obj.Locale)), squirrel.Expr("NULLIF(?, '')", valueOrEmpty(obj.Timezone)), seenAt, seenAt)
+	}
+
+	query = query.Suffix(`ON CONFLICT (phone) DO UPDATE SET
+		first_name = COALESCE(NULLIF(EXCLUDED.first_name, ''), customer.first_name),
+		last_name = COALESCE(NULLIF(EXCLUDED.last_name, ''), customer.last_name),
+		email = COALESCE(NULLIF(EXCLUDED.email, ''), customer.email),
+		locale = COALESCE(EXCLUDED.locale, customer.locale),
+		timezone = COALESCE(EXCLUDED.timezone, customer.timezone),
+		first_seen_at = LEAST(customer.first_seen_at, EXCLUDED.first_seen_at),
+		last_seen_at = GREATEST(customer.last_seen_at, EXCLUDED.last_seen_at)`)
+
+	sql, args, err := query.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	if _, err := r.Con.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("failed to execute batch upsert: %w", err)
+	}
+
+	return nil
+}
+
+// Update changes the locale and timezone of the customer, an empty value clears them.
+// It returns false if there is no such customer.
+func (r *Repo) Update(ctx context.Context, pars *model.GetPars, obj *model.Edit) (bool, error) {
+	if !pars.IsValid() || (obj.Locale == nil && obj.Timezone == nil) {
+		return false, errs.InvalidInput
+	}
+
+	queryBuilder := squirrel.Update("customer")
+
+	if obj.Locale != nil {
+		queryBuilder = queryBuilder.Set("locale", squirrel.Expr("NULLIF(?, '')", *obj.Locale))
+	}
+
+	if obj.Timezone != nil {
+		queryBuilder = queryBuilder.Set("timezone", squirrel.Expr("NULLIF(?, '')", *obj.Timezone))
+	}
+
+	if len(pars.ID) != 0 {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"id": pars.ID})
+	}
+
+	if len(pars.Phone) != 0 {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"phone": pars.Phone})
+	}
+
+	sql, args, err := queryBuilder.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return false, err
+	}
+
+	tag, err := r.Con.Exec(ctx, sql, args...)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// LinkOrders points orders of the phones to their customers, moving orders whose
+// phone has changed away from the previous customer, and recounts the orders
+// of every customer it touched.
+func (r *Repo) LinkOrders(ctx context.Context, phones []string) error {
+	_, err := r.linkOrders(ctx, phones)
+	return err
+}
+
+// LinkAllOrders is the catch-up pass for links missed by LinkOrders: it creates
+// customers for order phones that have none, then links every order to the
+// customer of its phone. It returns the number of orders linked.
+func (r *Repo) LinkAllOrders(ctx context.Context) (int64, error) {
+	_, err := r.Con.Exec(ctx, `
+		INSERT INTO customer (phone, first_name, first_seen_at, last_seen_at)
+		SELECT o.user_phone, (ARRAY_AGG(o.user_name ORDER BY o.created_at DESC))[1],
+			MIN(COALESCE(o.completed_at, o.created_at)), MAX(COALESCE(o.completed_at, o.created_at))
+		FROM ord o
+		WHERE NOT EXISTS (SELECT 1 FROM customer c WHERE c.phone = o.user_phone)
+		GROUP BY o.user_phone
+		ON CONFLICT (phone) DO NOTHING`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create missing customers: %w", err)
+	}
+
+	return r.linkOrders(ctx, nil)
+}
+
+// linkOrders links orders of the phones, or of all phones when phones is nil.
+func (r *Repo) linkOrders(ctx context.Context, phones []string) (_ int64, err error) {
+	tx, err := r.Con.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback(context.Background())
+		} else {
+			err = tx.Commit(context.Background())
+		}
+	}()
+
+	rows, err := tx.Query(ctx, `
+		WITH moved AS (
+			SELECT o.id, o.customer_id AS old_id, c.id AS new_id
+			FROM ord o
+			JOIN customer c ON c.phone = o.user_phone
+			WHERE o.customer_id IS DISTINCT FROM c.id AND ($1::text[] IS NULL OR o.user_phone = ANY($1))
+			FOR UPDATE OF o
+		)
+		UPDATE ord o SET customer_id = m.new_id
+		FROM moved m
+		WHERE o.id = m.id
+		RETURNING m.old_id, m.new_id`, phones)
+	if err != nil {
+		return 0, fmt.Errorf("failed to link orders: %w", err)
+	}
+
+	var (
+		linked  int64
+		touched []int64
+	)
+	for rows.Next() {
+		var oldID *int64
+		var newID int64
+		if err = rows.Scan(&oldID, &newID); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan linked order: %w", err)
+		}
+
+		linked++
+		touched = append(touched, newID)
+		if oldID != nil {
+			touched = append(touched, *oldID)
+		}
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to link orders: %w", err)
+	}
+
+	if len(touched) == 0 {
+		return 0, nil
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE customer c SET order_count = (SELECT COUNT(*) FROM ord o WHERE o.customer_id = c.id)
+		WHERE c.id = ANY($1)`, touched)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count orders: %w", err)
+	}
+
+	return linked, nil
+}
+
+func valueOrEmpty(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
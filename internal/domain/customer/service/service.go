@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"mb-feedback/internal/domain/customer/model"
+	"mb-feedback/internal/errs"
+)
+
+type Service struct {
+	repoDB RepoDBI
+}
+
+func New(repoDB RepoDBI) *Service {
+	return &Service{
+		repoDB: repoDB,
+	}
+}
+
+type RepoDBI interface {
+	Get(ctx context.Context, pars *model.GetPars) (*model.Customer, bool, error)
+	List(ctx context.Context, pars *model.ListPars) ([]*model.Customer, int64, error)
+	UpsertBatch(ctx context.Context, objects []*model.Edit) error
+	Update(ctx context.Context, pars *model.GetPars, obj *model.Edit) (bool, error)
+	LinkOrders(ctx context.Context, phones []string) error
+	LinkAllOrders(ctx context.Context) (int64, error)
+}
+
+func (s *Service) List(ctx context.Context, pars *model.ListPars) ([]*model.Customer, int64, error) {
+	return s.repoDB.List(ctx, pars)
+}
+
+func (s *Service) Get(ctx context.Context, pars *model.GetPars, errNE bool) (*model.Customer, bool, error) {
+	result, found, err := s.repoDB.Get(ctx, pars)
+	if err != nil {
+		return nil, false, fmt.Errorf("repoDb.Get: %w", err)
+	}
+	if !found {
+		if errNE {
+			return nil, false, errs.ObjectNotFound
+		}
+		return nil, false, nil
+	}
+
+	return result, found, nil
+}
+
+// Update changes the locale and timezone of the customer.
+func (s *Service) Update(ctx context.Context, pars *model.GetPars, obj *model.Edit) error {
+	updated, err := s.repoDB.Update(ctx, pars, obj)
+	if err != nil {
+		return fmt.Errorf("repoDb.Update: %w", err)
+	}
+	if !updated {
+		return errs.ObjectNotFound
+	}
+
+	return nil
+}
+
+// Sync upserts the customers seen in new orders and links those orders to them.
+// Customers are keyed by the normalized phone number.
+func (s *Service) Sync(ctx context.Context, objs []*model.Edit) error {
+	if len(objs) == 0 {
+		return nil
+	}
+
+	// one row per phone, otherwise the upsert would touch the same row twice
+	byPhone := make(map[string]*model.Edit, len(objs))
+	phones := make([]string, 0, len(objs))
+	for _, obj := range objs {
+		existing, ok := byPhone[obj.Phone]
+		if !ok {
+			byPhone[obj.Phone] = obj
+			phones = append(phones, obj.Phone)
+			continue
+		}
+		if obj.SeenAt != nil && (existing.SeenAt == nil || obj.SeenAt.After(*existing.SeenAt)) {
+			byPhone[obj.Phone] = obj
+		}
+	}
+
+	unique := make([]*model.Edit, 0, len(phones))
+	for _, phone := range phones {
+		unique = append(unique, byPhone[phone])
+	}
+
+	if err := s.repoDB.UpsertBatch(ctx, unique); err != nil {
+		return fmt.Errorf("repoDb.UpsertBatch: %w", err)
+	}
+
+	if err := s.repoDB.LinkOrders(ctx, phones); err != nil {
+		return fmt.Errorf("repoDb.LinkOrders: %w", err)
+	}
+
+	return nil
+}
+
+// RelinkOrders links every order to the customer of its current phone, catching up
+// on links a failed Sync left behind and on orders whose phone has changed.
+// It returns the number of orders linked.
+func (s *Service) RelinkOrders(ctx context.Context) (int64, error) {
+	result, err := s.repoDB.LinkAllOrders(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("repoDb.LinkAllOrders: %w", err)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// FeedbackDelay is how long after the order completion feedback is requested
+// for products of the category. An empty Category applies to all other categories.
+type FeedbackDelay struct {
+	Category  string
+	Delay     time.Duration
+	UpdatedAt time.Time
+}
+
+type GetPars struct {
+	Category string
+}
+
+type ListPars struct {
+	Categories *[]string
+}
+
+type Edit struct {
+	Category string
+	Delay    time.Duration
+}
@@ -0,0 +1,126 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"mb-feedback/internal/domain/feedback_delay/model"
+	"mb-feedback/internal/errs"
+	"time"
+)
+
+type Repo struct {
+	Con *pgxpool.Pool
+}
+
+func New(con *pgxpool.Pool) *Repo {
+	return &Repo{
+		con,
+	}
+}
+
+func (r *Repo) Get(ctx context.Context, pars *model.GetPars) (*model.FeedbackDelay, bool, error) {
+	var (
+		result       model.FeedbackDelay
+		delaySeconds int64
+	)
+
+	queryBuilder := squirrel.
+		Select("category", "delay_seconds", "updated_at").
+		From("feedback_delay").
+		Where(squirrel.Eq{"category": pars.Category}).
+		Limit(1)
+
+	sql, args, err := queryBuilder.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return nil, false, err
+	}
+
+	err = r.Con.QueryRow(ctx, sql, args...).Scan(&result.Category, &delaySeconds, &result.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	result.Delay = time.Duration(delaySeconds) * time.Second
+
+	return &result, true, nil
+}
+
+func (r *Repo) List(ctx context.Context, pars *model.ListPars) ([]*model.FeedbackDelay, int64, error) {
+	queryBuilder := squirrel.
+		Select("category", "delay_seconds", "updated_at").
+		From("feedback_delay").
+		OrderBy("category")
+
+	if pars.Categories != nil {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"category": *pars.Categories})
+	}
+
+	sql, args, err := queryBuilder.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.Con.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var result []*model.FeedbackDelay
+	for rows.Next() {
+		var (
+			data         model.FeedbackDelay
+			delaySeconds int64
+		)
+		if err = rows.Scan(&data.Category, &delaySeconds, &data.UpdatedAt); err != nil {
+			return nil, 0, err
+		}
+		data.Delay = time.Duration(delaySeconds) * time.Second
+
+		result = append(result, &data)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return result, int64(len(result)), nil
+}
+
+// Upsert sets the delay of the category, creating the rule if needed.
+func (r *Repo) Upsert(ctx context.Context, obj *model.Edit) error {
+	if obj.Delay < 0 {
+		return errs.InvalidInput
+	}
+
+	insert := squirrel.Insert("feedback_delay").
+		Columns("category", "delay_seconds").
+		Values(obj.Category, int64(obj.Delay/time.Second)).
+		Suffix("ON CONFLICT (category) DO UPDATE SET delay_seconds = EXCLUDED.delay_seconds, updated_at = NOW()").
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := insert.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.Con.Exec(ctx, query, args...)
+	return err
+}
+
+func (r *Repo) Delete(ctx context.Context, pars *model.GetPars) error {
+	queryBuilder := squirrel.Delete("feedback_delay").Where(squirrel.Eq{"category": pars.Category})
+
+	sql, args, err := queryBuilder.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.Con.Exec(ctx, sql, args...)
+	return err
+}
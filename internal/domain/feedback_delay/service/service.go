@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"mb-feedback/internal/domain/feedback_delay/model"
+	"mb-feedback/internal/errs"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cacheTTL is how long loaded rules are used before the table is read again,
+// so edits made directly in the database are picked up without a restart.
+const cacheTTL = time.Minute
+
+type Service struct {
+	repoDB RepoDBI
+
+	defaultDelay time.Duration
+
+	mu       sync.Mutex
+	cached   map[string]time.Duration
+	cachedAt time.Time
+}
+
+// New creates the service. defaultDelay applies to categories without a rule
+// when there is no rule for the empty category either.
+func New(repoDB RepoDBI, defaultDelay time.Duration) *Service {
+	return &Service{
+		repoDB:       repoDB,
+		defaultDelay: defaultDelay,
+	}
+}
+
+type RepoDBI interface {
+	Get(ctx context.Context, pars *model.GetPars) (*model.FeedbackDelay, bool, error)
+	List(ctx context.Context, pars *model.ListPars) ([]*model.FeedbackDelay, int64, error)
+	Upsert(ctx context.Context, obj *model.Edit) error
+	Delete(ctx context.Context, pars *model.GetPars) error
+}
+
+func (s *Service) List(ctx context.Context, pars *model.ListPars) ([]*model.FeedbackDelay, int64, error) {
+	return s.repoDB.List(ctx, pars)
+}
+
+func (s *Service) Get(ctx context.Context, pars *model.GetPars, errNE bool) (*model.FeedbackDelay, bool, error) {
+	result, found, err := s.repoDB.Get(ctx, pars)
+	if err != nil {
+		return nil, false, fmt.Errorf("repoDb.Get: %w", err)
+	}
+	if !found {
+		if errNE {
+			return nil, false, errs.ObjectNotFound
+		}
+		return nil, false, nil
+	}
+
+	return result, found, nil
+}
+
+// Set changes the delay of the category. Details created before keep their schedule.
+func (s *Service) Set(ctx context.Context, obj *model.Edit) error {
+	obj.Category = normalizeCategory(obj.Category)
+
+	if err := s.repoDB.Upsert(ctx, obj); err != nil {
+		return err
+	}
+
+	s.invalidate()
+
+	return nil
+}
+
+func (s *Service) Delete(ctx context.Context, pars *model.GetPars) error {
+	pars.Category = normalizeCategory(pars.Category)
+
+	if err := s.repoDB.Delete(ctx, pars); err != nil {
+		return err
+	}
+
+	s.invalidate()
+
+	return nil
+}
+
+// ScheduledAt returns when feedback on a product of the category is requested
+// for an order completed at completedAt.
+func (s *Service) ScheduledAt(ctx context.Context, completedAt time.Time, category string) (time.Time, error) {
+	delays, err := s.delays(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	delay, ok := delays[normalizeCategory(category)]
+	if !ok {
+		if delay, ok = delays[""]; !ok {
+			delay = s.defaultDelay
+		}
+	}
+
+	return completedAt.Add(delay), nil
+}
+
+func (s *Service) delays(ctx context.Context) (map[string]time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cached != nil && time.Since(s.cachedAt) < cacheTTL {
+		return s.cached, nil
+	}
+
+	rules, _, err := s.repoDB.List(ctx, &model.ListPars{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list feedback delays: %w", err)
+	}
+
+	result := make(map[string]time.Duration, len(rules))
+	for _, rule := range rules {
+		result[rule.Category] = rule.Delay
+	}
+
+	s.cached, s.cachedAt = result, time.Now()
+
+	return result, nil
+}
+
+func (s *Service) invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cached = nil
+}
+
+func normalizeCategory(category string) string {
+	return strings.ToLower(strings.TrimSpace(category))
+}
@@ -0,0 +1,45 @@
+package model
+
+import "time"
+
+// MessageTemplate maps a customer locale, provider and product category to a Voximplant template.
+// Empty Locale, Provider or Category match any value.
+type MessageTemplate struct {
+	ID         string
+	Locale     string
+	Provider   string
+	Category   string
+	TemplateID string
+	// Params are the template text params, values may contain placeholders like {user_name}
+	Params    map[string]string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type GetPars struct {
+	ID string
+}
+
+func (m *GetPars) IsValid() bool {
+	return m.ID != ""
+}
+
+type ListPars struct {
+	Locale   *string
+	Provider *string
+	Category *string
+}
+
+type Edit struct {
+	Locale     string
+	Provider   string
+	Category   string
+	TemplateID string
+	Params     map[string]string
+}
+
+// Resolved is the template picked for a message.
+type Resolved struct {
+	TemplateID string
+	Params     map[string]string
+}
@@ -0,0 +1,146 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"mb-feedback/internal/domain/message_template/model"
+	"mb-feedback/internal/errs"
+)
+
+type Repo struct {
+	Con *pgxpool.Pool
+}
+
+func New(con *pgxpool.Pool) *Repo {
+	return &Repo{
+		con,
+	}
+}
+
+func (r *Repo) Get(ctx context.Context, pars *model.GetPars) (*model.MessageTemplate, bool, error) {
+	if !pars.IsValid() {
+		return nil, false, errs.InvalidInput
+	}
+
+	var result model.MessageTemplate
+
+	queryBuilder := squirrel.
+		Select("id", "locale", "provider", "category", "template_id", "params", "created_at", "updated_at").
+		From("message_template").
+		Where(squirrel.Eq{"id": pars.ID}).
+		Limit(1)
+
+	sql, args, err := queryBuilder.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return nil, false, err
+	}
+
+	err = r.Con.QueryRow(ctx, sql, args...).Scan(
+		&result.ID, &result.Locale, &result.Provider, &result.Category, &result.TemplateID, &result.Params,
+		&result.CreatedAt, &result.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	return &result, true, nil
+}
+
+func (r *Repo) List(ctx context.Context, pars *model.ListPars) ([]*model.MessageTemplate, int64, error) {
+	queryBuilder := squirrel.
+		Select("id", "locale", "provider", "category", "template_id", "params", "created_at", "updated_at").
+		From("message_template").
+		OrderBy("locale", "provider", "category")
+
+	if pars.Locale != nil {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"locale": pars.Locale})
+	}
+
+	if pars.Provider != nil {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"provider": pars.Provider})
+	}
+
+	if pars.Category != nil {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"category": pars.Category})
+	}
+
+	sql, args, err := queryBuilder.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.Con.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var result []*model.MessageTemplate
+	for rows.Next() {
+		var data model.MessageTemplate
+		err = rows.Scan(
+			&data.ID, &data.Locale, &data.Provider, &data.Category, &data.TemplateID, &data.Params,
+			&data.CreatedAt, &data.UpdatedAt)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		result = append(result, &data)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return result, int64(len(result)), nil
+}
+
+// Upsert creates the template for the locale, provider and category or replaces the existing one.
+func (r *Repo) Upsert(ctx context.Context, obj *model.Edit) (string, error) {
+	if obj.TemplateID == "" {
+		return "", errs.InvalidInput
+	}
+
+	params := obj.Params
+	if params == nil {
+		params = map[string]string{}
+	}
+
+	insert := squirrel.Insert("message_template").
+		Columns("locale", "provider", "category", "template_id", "params").
+		Values(obj.Locale, obj.Provider, obj.Category, obj.TemplateID, params).
+		Suffix("ON CONFLICT (locale, provider, category) DO UPDATE SET template_id = EXCLUDED.template_id, params = EXCLUDED.params, updated_at = NOW() RETURNING id").
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := insert.ToSql()
+	if err != nil {
+		return "", err
+	}
+
+	var id string
+	if err = r.Con.QueryRow(ctx, query, args...).Scan(&id); err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+func (r *Repo) Delete(ctx context.Context, pars *model.GetPars) error {
+	if !pars.IsValid() {
+		return errs.InvalidInput
+	}
+
+	queryBuilder := squirrel.Delete("message_template").Where(squirrel.Eq{"id": pars.ID})
+
+	sql, args, err := queryBuilder.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.Con.Exec(ctx, sql, args...)
+	return err
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"mb-feedback/internal/domain/message_template/model"
+	"mb-feedback/internal/errs"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cacheTTL is how long loaded templates are used before the table is read again,
+// so edits made directly in the database are picked up without a restart.
+const cacheTTL = time.Minute
+
+type Service struct {
+	repoDB RepoDBI
+
+	fallback *model.Resolved
+
+	mu       sync.Mutex
+	cached   []*model.MessageTemplate
+	cachedAt time.Time
+}
+
+// New creates the service. fallback is used when no template matches.
+func New(repoDB RepoDBI, fallback *model.Resolved) *Service {
+	return &Service{
+		repoDB:   repoDB,
+		fallback: fallback,
+	}
+}
+
+type RepoDBI interface {
+	Get(ctx context.Context, pars *model.GetPars) (*model.MessageTemplate, bool, error)
+	List(ctx context.Context, pars *model.ListPars) ([]*model.MessageTemplate, int64, error)
+	Upsert(ctx context.Context, obj *model.Edit) (string, error)
+	Delete(ctx context.Context, pars *model.GetPars) error
+}
+
+func (s *Service) List(ctx context.Context, pars *model.ListPars) ([]*model.MessageTemplate, int64, error) {
+	return s.repoDB.List(ctx, pars)
+}
+
+func (s *Service) Get(ctx context.Context, pars *model.GetPars, errNE bool) (*model.MessageTemplate, bool, error) {
+	result, found, err := s.repoDB.Get(ctx, pars)
+	if err != nil {
+		return nil, false, fmt.Errorf("repoDb.Get: %w", err)
+	}
+	if !found {
+		if errNE {
+			return nil, false, errs.ObjectNotFound
+		}
+		return nil, false, nil
+	}
+
+	return result, found, nil
+}
+
+// Set creates or replaces the template for the locale, provider and category.
+func (s *Service) Set(ctx context.Context, obj *model.Edit) (string, error) {
+	obj.Locale = strings.ToLower(strings.TrimSpace(obj.Locale))
+	obj.Provider = strings.TrimSpace(obj.Provider)
+	obj.Category = strings.TrimSpace(obj.Category)
+
+	id, err := s.repoDB.Upsert(ctx, obj)
+	if err != nil {
+		return "", err
+	}
+
+	s.invalidate()
+
+	return id, nil
+}
+
+func (s *Service) Delete(ctx context.Context, pars *model.GetPars) error {
+	if err := s.repoDB.Delete(ctx, pars); err != nil {
+		return err
+	}
+
+	s.invalidate()
+
+	return nil
+}
+
+// Resolve picks the most specific template matching the locale, provider and category.
+// A template matches when each of its fields is empty or equal to the given value;
+// a matching locale outweighs a matching category, which outweighs a matching provider.
+// The fallback template is returned when nothing matches.
+func (s *Service) Resolve(ctx context.Context, locale, provider, category string) (*model.Resolved, error) {
+	templates, err := s.templates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	locale = strings.ToLower(locale)
+
+	var best *model.MessageTemplate
+	bestScore := -1
+	for _, t := range templates {
+		score, ok := match(t, locale, provider, category)
+		if ok && score > bestScore {
+			best, bestScore = t, score
+		}
+	}
+
+	if best == nil {
+		return s.fallback, nil
+	}
+
+	return &model.Resolved{
+		TemplateID: best.TemplateID,
+		Params:     best.Params,
+	}, nil
+}
+
+func match(t *model.MessageTemplate, locale, provider, category string) (int, bool) {
+	score := 0
+
+	for _, field := range []struct {
+		want, got string
+		weight    int
+	}{
+		{t.Locale, locale, 4},
+		{t.Category, category, 2},
+		{t.Provider, provider, 1},
+	} {
+		if field.want == "" {
+			continue
+		}
+		if !strings.EqualFold(field.want, field.got) {
+			return 0, false
+		}
+		score += field.weight
+	}
+
+	return score, true
+}
+
+func (s *Service) templates(ctx context.Context) ([]*model.MessageTemplate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cached != nil && time.Since(s.cachedAt) < cacheTTL {
+		return s.cached, nil
+	}
+
+	result, _, err := s.repoDB.List(ctx, &model.ListPars{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list message templates: %w", err)
+	}
+	if result == nil {
+		result = []*model.MessageTemplate{}
+	}
+
+	s.cached, s.cachedAt = result, time.Now()
+
+	return result, nil
+}
+
+func (s *Service) invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.cached = nil
+}
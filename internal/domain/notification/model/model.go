@@ -7,19 +7,35 @@ type Notification struct {
 	OrderItemID string
 	PhoneNumber string
 	Status      string
-	SentAt      time.Time
+	Reason      string
+	SentAt      *time.Time
 	CreatedAt   time.Time
+
+	// Voximplant response to the send request
+	ProviderMessageID string
+	ResponseCode      int
+	RawResponse       string
+
+	// delivery status changes reported by Voximplant
+	DeliveredAt   *time.Time
+	ReadAt        *time.Time
+	UndeliveredAt *time.Time
+
+	AttemptCount  int
+	NextAttemptAt *time.Time
+	LastError     string
 }
 
 type GetPars struct {
-	ID          string
-	OrderItemID string
-	PhoneNumber string
-	Status      string
+	ID                string
+	OrderItemID       string
+	PhoneNumber       string
+	Status            string
+	ProviderMessageID string
 }
 
 func (m *GetPars) IsValid() bool {
-	return m.ID != "" || m.OrderItemID != "" || m.PhoneNumber != "" || m.Status != ""
+	return m.ID != "" || m.OrderItemID != "" || m.PhoneNumber != "" || m.Status != "" || m.ProviderMessageID != ""
 }
 
 type ListPars struct {
@@ -35,6 +51,12 @@ type ListPars struct {
 	SentAfter     *time.Time
 	CreatedBefore *time.Time
 	CreatedAfter  *time.Time
+
+	ProviderMessageID *string
+	// NextAttemptBefore selects notifications due for a retry
+	NextAttemptBefore *time.Time
+	// Limit caps the number of the most recently created notifications returned
+	Limit *uint64
 }
 
 type Edit struct {
@@ -42,5 +64,18 @@ type Edit struct {
 	OrderItemID *string
 	PhoneNumber *string
 	Status      *string
+	Reason      *string
 	SentAt      *time.Time
+
+	ProviderMessageID *string
+	ResponseCode      *int
+	RawResponse       *string
+
+	DeliveredAt   *time.Time
+	ReadAt        *time.Time
+	UndeliveredAt *time.Time
+
+	AttemptCount  *int
+	NextAttemptAt *time.Time
+	LastError     *string
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/Masterminds/squirrel"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
+	"log/slog"
 	"mb-feedback/internal/domain/notification/model"
 	"mb-feedback/internal/errs"
 )
@@ -27,7 +28,13 @@ func (r *Repo) Get(ctx context.Context, pars *model.GetPars) (*model.Notificatio
 
 	var result model.Notification
 
-	queryBuilder := squirrel.Select("*").From("notification")
+	queryBuilder := squirrel.
+		Select(
+			"id", "order_item_id", "phone_number", "status", "COALESCE(reason, '')", "sent_at", "created_at",
+			"COALESCE(provider_message_id, '')", "COALESCE(response_code, 0)", "COALESCE(raw_response, '')",
+			"delivered_at", "read_at", "undelivered_at",
+			"attempt_count", "next_attempt_at", "COALESCE(last_error, '')").
+		From("notification")
 
 	if len(pars.ID) != 0 {
 		queryBuilder = queryBuilder.Where(squirrel.Eq{"id": pars.ID})
@@ -45,6 +52,10 @@ func (r *Repo) Get(ctx context.Context, pars *model.GetPars) (*model.Notificatio
 		queryBuilder = queryBuilder.Where(squirrel.Eq{"status": pars.Status})
 	}
 
+	if len(pars.ProviderMessageID) != 0 {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"provider_message_id": pars.ProviderMessageID})
+	}
+
 	queryBuilder = queryBuilder.Limit(1)
 
 	sql, args, err := queryBuilder.PlaceholderFormat(squirrel.Dollar).ToSql()
@@ -52,7 +63,11 @@ func (r *Repo) Get(ctx context.Context, pars *model.GetPars) (*model.Notificatio
 		return nil, false, err
 	}
 
-	err = r.Con.QueryRow(ctx, sql, args...).Scan(&result.ID, &result.OrderItemID, &result.PhoneNumber, &result.Status, &result.CreatedAt)
+	err = r.Con.QueryRow(ctx, sql, args...).Scan(
+		&result.ID, &result.OrderItemID, &result.PhoneNumber, &result.Status, &result.Reason, &result.SentAt, &result.CreatedAt,
+		&result.ProviderMessageID, &result.ResponseCode, &result.RawResponse,
+		&result.DeliveredAt, &result.ReadAt, &result.UndeliveredAt,
+		&result.AttemptCount, &result.NextAttemptAt, &result.LastError)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, false, nil
@@ -65,9 +80,70 @@ func (r *Repo) Get(ctx context.Context, pars *model.GetPars) (*model.Notificatio
 
 func (r *Repo) List(ctx context.Context, pars *model.ListPars) ([]*model.Notification, int64, error) {
 	queryBuilder := squirrel.
-		Select("id", "order_item_id", "phone_number", "status", "sent_at", "created_at").
+		Select(
+			"id", "order_item_id", "phone_number", "status", "COALESCE(reason, '')", "sent_at", "created_at",
+			"COALESCE(provider_message_id, '')", "COALESCE(response_code, 0)", "COALESCE(raw_response, '')",
+			"delivered_at", "read_at", "undelivered_at",
+			"attempt_count", "next_attempt_at", "COALESCE(last_error, '')").
 		From("notification")
 
+	queryBuilder = listFilter(queryBuilder, pars)
+
+	if pars.Limit != nil {
+		queryBuilder = queryBuilder.OrderBy("created_at DESC", "id DESC").Limit(*pars.Limit)
+	}
+
+	sql, args, err := queryBuilder.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.Con.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer rows.Close()
+
+	var result []*model.Notification
+	for rows.Next() {
+		var data model.Notification
+		err = rows.Scan(
+			&data.ID, &data.OrderItemID, &data.PhoneNumber, &data.Status, &data.Reason, &data.SentAt, &data.CreatedAt,
+			&data.ProviderMessageID, &data.ResponseCode, &data.RawResponse,
+			&data.DeliveredAt, &data.ReadAt, &data.UndeliveredAt,
+			&data.AttemptCount, &data.NextAttemptAt, &data.LastError)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		result = append(result, &data)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return result, int64(len(result)), nil
+}
+
+// Count returns the number of notifications matching the filter, Limit is ignored.
+func (r *Repo) Count(ctx context.Context, pars *model.ListPars) (int64, error) {
+	queryBuilder := listFilter(squirrel.Select("COUNT(*)").From("notification"), pars)
+
+	sql, args, err := queryBuilder.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var result int64
+	if err = r.Con.QueryRow(ctx, sql, args...).Scan(&result); err != nil {
+		return 0, err
+	}
+
+	return result, nil
+}
+
+func listFilter(queryBuilder squirrel.SelectBuilder, pars *model.ListPars) squirrel.SelectBuilder {
 	if pars.ID != nil {
 		queryBuilder = queryBuilder.Where(squirrel.Eq{"id": pars.ID})
 	}
@@ -81,7 +157,7 @@ func (r *Repo) List(ctx context.Context, pars *model.ListPars) ([]*model.Notific
 	}
 
 	if pars.OrderItemIDs != nil {
-		queryBuilder = queryBuilder.Where(squirrel.Eq{"order_item_id": pars.OrderItemID})
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"order_item_id": *pars.OrderItemIDs})
 	}
 
 	if pars.PhoneNumber != nil {
@@ -97,50 +173,49 @@ func (r *Repo) List(ctx context.Context, pars *model.ListPars) ([]*model.Notific
 	}
 
 	if pars.Statuses != nil {
-		queryBuilder = queryBuilder.Where(squirrel.Eq{"status": pars.Status})
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"status": *pars.Statuses})
 	}
 
-	if pars.CreatedBefore != nil {
-		queryBuilder = queryBuilder.Where(squirrel.LtOrEq{"created_at": pars.CreatedBefore})
+	if pars.ProviderMessageID != nil {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"provider_message_id": pars.ProviderMessageID})
 	}
 
-	if pars.CreatedAfter != nil {
-		queryBuilder = queryBuilder.Where(squirrel.GtOrEq{"created_at": pars.CreatedAfter})
+	if pars.SentBefore != nil {
+		queryBuilder = queryBuilder.Where(squirrel.LtOrEq{"sent_at": pars.SentBefore})
 	}
 
-	sql, args, err := queryBuilder.PlaceholderFormat(squirrel.Dollar).ToSql()
-	if err != nil {
-		return nil, 0, err
+	if pars.SentAfter != nil {
+		queryBuilder = queryBuilder.Where(squirrel.GtOrEq{"sent_at": pars.SentAfter})
 	}
 
-	rows, err := r.Con.Query(ctx, sql, args...)
-	if err != nil {
-		return nil, 0, err
+	if pars.CreatedBefore != nil {
+		queryBuilder = queryBuilder.Where(squirrel.LtOrEq{"created_at": pars.CreatedBefore})
 	}
 
-	defer rows.Close()
-
-	var result []*model.Notification
-	for rows.Next() {
-		var data model.Notification
-		err = rows.Scan(&data.ID, &data.OrderItemID, &data.PhoneNumber, &data.Status, &data.CreatedAt)
-		if err != nil {
-			return nil, 0, err
-		}
-
-		result = append(result, &data)
+	if pars.CreatedAfter != nil {
+		queryBuilder = queryBuilder.Where(squirrel.GtOrEq{"created_at": pars.CreatedAfter})
 	}
-	if err = rows.Err(); err != nil {
-		return nil, 0, err
+
+	if pars.NextAttemptBefore != nil {
+		queryBuilder = queryBuilder.Where(squirrel.LtOrEq{"next_attempt_at": pars.NextAttemptBefore})
 	}
 
-	return result, int64(len(result)), nil
+	return queryBuilder
 }
 
 func (r *Repo) Create(ctx context.Context, obj *model.Edit) error {
+	attemptCount := 1
+	if obj.AttemptCount != nil {
+		attemptCount = *obj.AttemptCount
+	}
+
 	insert := squirrel.Insert("notification").
-		Columns("order_item_id", "phone_number", "status", "sent_at").
-		Values(obj.OrderItemID, obj.PhoneNumber, obj.Status, obj.SentAt).
+		Columns("order_item_id", "phone_number", "status", "reason", "sent_at",
+			"provider_message_id", "response_code", "raw_response",
+			"attempt_count", "next_attempt_at", "last_error").
+		Values(obj.OrderItemID, obj.PhoneNumber, obj.Status, obj.Reason, obj.SentAt,
+			obj.ProviderMessageID, obj.ResponseCode, obj.RawResponse,
+			attemptCount, obj.NextAttemptAt, obj.LastError).
 		PlaceholderFormat(squirrel.Dollar)
 
 	query, args, err := insert.ToSql()
@@ -166,10 +241,70 @@ func (r *Repo) Update(ctx context.Context, pars *model.GetPars, obj *model.Edit)
 		queryBuilder = queryBuilder.Set("status", obj.Status)
 	}
 
+	if obj.Reason != nil {
+		queryBuilder = queryBuilder.Set("reason", obj.Reason)
+	}
+
 	if obj.SentAt != nil {
 		queryBuilder = queryBuilder.Set("sent_at", obj.SentAt)
 	}
 
+	if obj.ProviderMessageID != nil {
+		queryBuilder = queryBuilder.Set("provider_message_id", obj.ProviderMessageID)
+	}
+
+	if obj.ResponseCode != nil {
+		queryBuilder = queryBuilder.Set("response_code", obj.ResponseCode)
+	}
+
+	if obj.RawResponse != nil {
+		queryBuilder = queryBuilder.Set("raw_response", obj.RawResponse)
+	}
+
+	if obj.DeliveredAt != nil {
+		queryBuilder = queryBuilder.Set("delivered_at", obj.DeliveredAt)
+	}
+
+	if obj.ReadAt != nil {
+		queryBuilder = queryBuilder.Set("read_at", obj.ReadAt)
+	}
+
+	if obj.UndeliveredAt != nil {
+		queryBuilder = queryBuilder.Set("undelivered_at", obj.UndeliveredAt)
+	}
+
+	if obj.AttemptCount != nil {
+		queryBuilder = queryBuilder.Set("attempt_count", obj.AttemptCount)
+	}
+
+	if obj.NextAttemptAt != nil {
+		queryBuilder = queryBuilder.Set("next_attempt_at", obj.NextAttemptAt)
+	}
+
+	if obj.LastError != nil {
+		queryBuilder = queryBuilder.Set("last_error", obj.LastError)
+	}
+
+	if pars.ID != "" {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"id": pars.ID})
+	}
+
+	if pars.OrderItemID != "" {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"order_item_id": pars.OrderItemID})
+	}
+
+	if pars.PhoneNumber != "" {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"phone_number": pars.PhoneNumber})
+	}
+
+	if pars.Status != "" {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"status": pars.Status})
+	}
+
+	if pars.ProviderMessageID != "" {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"provider_message_id": pars.ProviderMessageID})
+	}
+
 	sql, args, err := queryBuilder.PlaceholderFormat(squirrel.Dollar).ToSql()
 	if err != nil {
 		return err
@@ -202,3 +337,30 @@ func (r *Repo) Delete(ctx context.Context, pars *model.GetPars) error {
 	_, err = r.Con.Exec(ctx, sql, args...)
 	return err
 }
+
+// phoneLockSpace keeps phone advisory locks apart from other advisory locks of the database.
+const phoneLockSpace = 1
+
+// LockPhone waits for the advisory lock of the phone number, held by a connection of its own
+// until the returned function is called. Runs and instances that take it check the frequency
+// caps and store notifications to the customer one at a time.
+func (r *Repo) LockPhone(ctx context.Context, phone string) (func(), error) {
+	conn, err := r.Con.Acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = conn.Exec(ctx, "SELECT pg_advisory_lock($1, hashtext($2))", phoneLockSpace, phone); err != nil {
+		conn.Release()
+		return nil, err
+	}
+
+	return func() {
+		if _, err := conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1, hashtext($2))", phoneLockSpace, phone); err != nil {
+			// a session that may still hold the lock must not go back to the pool
+			slog.Error("Failed to release phone lock", "phone", phone, "error", err)
+			_ = conn.Conn().Close(context.Background())
+		}
+		conn.Release()
+	}, nil
+}
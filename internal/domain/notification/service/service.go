@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"mb-feedback/internal/client/notifier"
+	"mb-feedback/internal/cns"
 	"mb-feedback/internal/domain/notification/model"
 	"mb-feedback/internal/errs"
+	"time"
 )
 
 type Service struct {
@@ -23,19 +25,39 @@ func New(repoDB RepoDBI, notifier notifier.Notifier) *Service {
 type RepoDBI interface {
 	Get(ctx context.Context, pars *model.GetPars) (*model.Notification, bool, error)
 	List(ctx context.Context, pars *model.ListPars) ([]*model.Notification, int64, error)
+	Count(ctx context.Context, pars *model.ListPars) (int64, error)
 	Create(ctx context.Context, obj *model.Edit) error
 	Update(ctx context.Context, pars *model.GetPars, obj *model.Edit) error
 	Delete(ctx context.Context, pars *model.GetPars) error
+	LockPhone(ctx context.Context, phone string) (func(), error)
 }
 
 func (s *Service) list(ctx context.Context, pars *model.ListPars) ([]*model.Notification, int64, error) {
 	return s.repoDB.List(ctx, pars)
 }
 
+func (s *Service) List(ctx context.Context, pars *model.ListPars) ([]*model.Notification, int64, error) {
+	return s.list(ctx, pars)
+}
+
+func (s *Service) Count(ctx context.Context, pars *model.ListPars) (int64, error) {
+	return s.repoDB.Count(ctx, pars)
+}
+
 func (s *Service) Create(ctx context.Context, obj *model.Edit) error {
 	return s.repoDB.Create(ctx, obj)
 }
 
+// LockPhone serializes notifying the customer with the phone number until the returned function is called.
+func (s *Service) LockPhone(ctx context.Context, phone string) (func(), error) {
+	unlock, err := s.repoDB.LockPhone(ctx, phone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock phone %s: %w", phone, err)
+	}
+
+	return unlock, nil
+}
+
 func (s *Service) get(ctx context.Context, pars *model.GetPars, errNE bool) (*model.Notification, bool, error) {
 	result, found, err := s.repoDB.Get(ctx, pars)
 	if err != nil {
@@ -55,10 +77,65 @@ func (s *Service) update(ctx context.Context, pars *model.GetPars, obj *model.Ed
 	return s.repoDB.Update(ctx, pars, obj)
 }
 
+func (s *Service) Update(ctx context.Context, pars *model.GetPars, obj *model.Edit) error {
+	return s.update(ctx, pars, obj)
+}
+
 func (s *Service) delete(ctx context.Context, pars *model.GetPars) error {
 	return s.repoDB.Delete(ctx, pars)
 }
 
-func (s *Service) Notify(ctx context.Context, orderID, userPhone, userName, productCode string) error {
-	return s.notifier.SendNotification(ctx, orderID, userPhone, userName, productCode)
+func (s *Service) Notify(ctx context.Context, msg *notifier.Message) (*notifier.Result, error) {
+	return s.notifier.SendNotification(ctx, msg)
+}
+
+// deliveryRank orders notification statuses by delivery progress.
+// A status only moves to one with a higher rank, so late or repeated
+// callbacks never move a READ notification back to DELIVERED.
+var deliveryRank = map[string]int{
+	cns.StatusSent:        1,
+	cns.StatusDelivered:   2,
+	cns.StatusUndelivered: 2,
+	cns.StatusRead:        3,
+}
+
+// ApplyDeliveryStatus moves the notification sent as the provider message to the reported
+// delivery status and records when it happened. It returns false when the status
+// would not move forward, e.g. for repeated or out-of-order callbacks.
+func (s *Service) ApplyDeliveryStatus(ctx context.Context, providerMessageID, status string, ts time.Time) (bool, error) {
+	newRank, ok := deliveryRank[status]
+	if !ok || status == cns.StatusSent {
+		return false, fmt.Errorf("%w: unknown delivery status %s", errs.InvalidInput, status)
+	}
+
+	notification, _, err := s.get(ctx, &model.GetPars{ProviderMessageID: providerMessageID}, true)
+	if err != nil {
+		return false, err
+	}
+
+	if deliveryRank[notification.Status] >= newRank {
+		return false, nil
+	}
+
+	obj := &model.Edit{Status: &status}
+	switch status {
+	case cns.StatusDelivered:
+		obj.DeliveredAt = &ts
+	case cns.StatusRead:
+		obj.ReadAt = &ts
+		// read implies delivered, the delivered callback may be lost or late
+		if notification.DeliveredAt == nil {
+			obj.DeliveredAt = &ts
+		}
+	case cns.StatusUndelivered:
+		obj.UndeliveredAt = &ts
+	}
+
+	// matching the current status too keeps a concurrent callback from being overwritten
+	err = s.update(ctx, &model.GetPars{ID: notification.ID, Status: notification.Status}, obj)
+	if err != nil {
+		return false, fmt.Errorf("failed to update notification %s: %w", notification.ID, err)
+	}
+
+	return true, nil
 }
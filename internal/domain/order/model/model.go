@@ -4,14 +4,30 @@ import "time"
 
 type Order struct {
 	ID              string
+	Provider        string
 	ExternalOrderID string
+	CustomerID      string
 	UserPhone       string
 	UserName        string
+	UserLastName    string
+	UserEmail       string
+	Status          string
+	CompletedAt     time.Time
 	CreatedAt       time.Time
 }
 
+// Provider describes a marketplace orders are imported from through mb-broker.
+type Provider struct {
+	ID       string
+	Status   string
+	PageSize int
+	// StatusChanges are order statuses polled to catch orders changed after import, e.g. RETURNED.
+	StatusChanges []string
+}
+
 type GetPars struct {
 	ID              string
+	Provider        string
 	ExternalOrderID string
 	UserPhone       string
 }
@@ -23,17 +39,25 @@ func (m *GetPars) IsValid() bool {
 type ListPars struct {
 	ID               *string
 	IDs              *[]string
+	Provider         *string
+	Providers        *[]string
 	ExternalOrderID  *string
 	ExternalOrderIDs *[]string
 	UserPhone        *string
 	UserPhones       *[]string
+	CustomerID       *string
+	Status           *string
+	Statuses         *[]string
 	CreatedBefore    *time.Time
 	CreatedAfter     *time.Time
 }
 
 type Edit struct {
+	Provider        string
 	ExternalOrderID string
 	UserPhone       *string
 	UserName        *string
+	Status          *string
+	CompletedAt     *time.Time
 	CreatedAt       *time.Time
 }
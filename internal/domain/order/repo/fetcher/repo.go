@@ -2,25 +2,31 @@ package fetcher
 
 import (
 	"context"
-	mb_broker "mb-feedback/internal/client/fetcher/mb-broker"
+	"errors"
+	"mb-feedback/internal/client/fetcher"
 	orderModel "mb-feedback/internal/domain/order/model"
+	"mb-feedback/internal/errs"
+	"time"
 )
 
 type Repo struct {
-	client *mb_broker.Client
+	client fetcher.Fetcher
 }
 
-func New(client *mb_broker.Client) *Repo {
+func New(client fetcher.Fetcher) *Repo {
 	return &Repo{
 		client: client,
 	}
 }
 
-func (r *Repo) FetchOrders(ctx context.Context) ([]*orderModel.Order, error) {
-	result, err := r.client.FetchCompletedOrders(ctx)
-	if err != nil {
+// FetchOrders returns the provider orders completed after completedAfter.
+// A fetch cut short by the source page limit returns the orders fetched so far
+// together with an errs.Truncated error.
+func (r *Repo) FetchOrders(ctx context.Context, provider *orderModel.Provider, completedAfter *time.Time) ([]*orderModel.Order, error) {
+	result, err := r.client.FetchCompletedOrders(ctx, provider, completedAfter)
+	if err != nil && !errors.Is(err, errs.Truncated) {
 		return nil, err
 	}
 
-	return result, nil
+	return result, err
 }
@@ -28,12 +28,18 @@ func (r *Repo) Get(ctx context.Context, pars *model.GetPars) (*model.Order, bool
 
 	var result model.Order
 
-	queryBuilder := squirrel.Select("*").From("ord")
+	queryBuilder := squirrel.
+		Select("id", "provider", "external_order_id", "COALESCE(customer_id::text, '')", "user_phone", "user_name", "status", "COALESCE(completed_at, created_at)", "created_at").
+		From("ord")
 
 	if len(pars.ID) != 0 {
 		queryBuilder = queryBuilder.Where(squirrel.Eq{"id": pars.ID})
 	}
 
+	if len(pars.Provider) != 0 {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"provider": pars.Provider})
+	}
+
 	if len(pars.ExternalOrderID) != 0 {
 		queryBuilder = queryBuilder.Where(squirrel.Eq{"external_order_id": pars.ExternalOrderID})
 	}
@@ -49,7 +55,7 @@ func (r *Repo) Get(ctx context.Context, pars *model.GetPars) (*model.Order, bool
 		return nil, false, err
 	}
 
-	err = r.Con.QueryRow(ctx, sql, args...).Scan(&result.ID, &result.ExternalOrderID, &result.UserPhone, &result.UserName, &result.CreatedAt)
+	err = r.Con.QueryRow(ctx, sql, args...).Scan(&result.ID, &result.Provider, &result.ExternalOrderID, &result.CustomerID, &result.UserPhone, &result.UserName, &result.Status, &result.CompletedAt, &result.CreatedAt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, false, nil
@@ -62,7 +68,7 @@ func (r *Repo) Get(ctx context.Context, pars *model.GetPars) (*model.Order, bool
 
 func (r *Repo) List(ctx context.Context, pars *model.ListPars) ([]*model.Order, int64, error) {
 	queryBuilder := squirrel.
-		Select("id", "external_order_id", "user_phone", "user_name", "created_at").
+		Select("id", "provider", "external_order_id", "COALESCE(customer_id::text, '')", "user_phone", "user_name", "status", "COALESCE(completed_at, created_at)", "created_at").
 		From("ord")
 
 	if pars.ID != nil {
@@ -73,6 +79,14 @@ func (r *Repo) List(ctx context.Context, pars *model.ListPars) ([]*model.Order,
 		queryBuilder = queryBuilder.Where(squirrel.Eq{"id": pars.IDs})
 	}
 
+	if pars.Provider != nil {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"provider": pars.Provider})
+	}
+
+	if pars.Providers != nil {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"provider": pars.Providers})
+	}
+
 	if pars.ExternalOrderID != nil {
 		queryBuilder = queryBuilder.Where(squirrel.Eq{"external_order_id": pars.ExternalOrderID})
 	}
@@ -89,6 +103,18 @@ func (r *Repo) List(ctx context.Context, pars *model.ListPars) ([]*model.Order,
 		queryBuilder = queryBuilder.Where(squirrel.Eq{"user_phone": pars.UserPhones})
 	}
 
+	if pars.CustomerID != nil {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"customer_id": pars.CustomerID})
+	}
+
+	if pars.Status != nil {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"status": pars.Status})
+	}
+
+	if pars.Statuses != nil {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"status": pars.Statuses})
+	}
+
 	if pars.CreatedBefore != nil {
 		queryBuilder = queryBuilder.Where(squirrel.LtOrEq{"created_at": pars.CreatedBefore})
 	}
@@ -112,7 +138,7 @@ func (r *Repo) List(ctx context.Context, pars *model.ListPars) ([]*model.Order,
 	var result []*model.Order
 	for rows.Next() {
 		var data model.Order
-		err = rows.Scan(&data.ID, &data.ExternalOrderID, &data.UserPhone, &data.UserName, &data.CreatedAt)
+		err = rows.Scan(&data.ID, &data.Provider, &data.ExternalOrderID, &data.CustomerID, &data.UserPhone, &data.UserName, &data.Status, &data.CompletedAt, &data.CreatedAt)
 		if err != nil {
 			return nil, 0, err
 		}
@@ -128,10 +154,17 @@ func (r *Repo) List(ctx context.Context, pars *model.ListPars) ([]*model.Order,
 
 func (r *Repo) ListOrdersNotInDetails(ctx context.Context, pars *model.ListPars) ([]*model.Order, error) {
 	queryBuilder := squirrel.
-		Select("o.id", "o.external_order_id", "o.user_phone", "o.user_name", "o.created_at").
+		Select("o.id", "o.provider", "o.external_order_id", "COALESCE(o.customer_id::text, '')", "o.user_phone", "o.user_name", "o.status", "COALESCE(o.completed_at, o.created_at)", "o.created_at").
 		From("ord o").
 		LeftJoin("ord_detail od ON o.id = od.order_id").
-		Where("od.order_id IS NULL")
+		Where("od.order_id IS NULL").
+		Where("o.items_fetched_at IS NULL")
+	if pars.Provider != nil {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"o.provider": pars.Provider})
+	}
+	if pars.Providers != nil {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"o.provider": pars.Providers})
+	}
 	if pars.CreatedAfter != nil {
 		queryBuilder = queryBuilder.Where(squirrel.GtOrEq{"o.created_at": pars.CreatedAfter})
 	}
@@ -150,7 +183,7 @@ func (r *Repo) ListOrdersNotInDetails(ctx context.Context, pars *model.ListPars)
 	var orders []*model.Order
 	for rows.Next() {
 		var order model.Order
-		if err := rows.Scan(&order.ID, &order.ExternalOrderID, &order.UserPhone, &order.UserName, &order.CreatedAt); err != nil {
+		if err := rows.Scan(&order.ID, &order.Provider, &order.ExternalOrderID, &order.CustomerID, &order.UserPhone, &order.UserName, &order.Status, &order.CompletedAt, &order.CreatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
 		orders = append(orders, &order)
@@ -165,8 +198,8 @@ func (r *Repo) ListOrdersNotInDetails(ctx context.Context, pars *model.ListPars)
 
 func (r *Repo) Create(ctx context.Context, obj *model.Edit) error {
 	insert := squirrel.Insert("ord").
-		Columns("external_order_id", "user_phone", "user_name").
-		Values(obj.ExternalOrderID, obj.UserPhone, obj.UserName).
+		Columns("provider", "external_order_id", "user_phone", "user_name", "completed_at").
+		Values(obj.Provider, obj.ExternalOrderID, obj.UserPhone, obj.UserName, obj.CompletedAt).
 		PlaceholderFormat(squirrel.Dollar)
 
 	query, args, err := insert.ToSql()
@@ -181,12 +214,36 @@ func (r *Repo) Create(ctx context.Context, obj *model.Edit) error {
 	return nil
 }
 
+func (r *Repo) CreateIfNotExistsTx(ctx context.Context, tx pgx.Tx, obj *model.Edit) (string, bool, error) {
+	insert := squirrel.Insert("ord").
+		Columns("provider", "external_order_id", "user_phone", "user_name", "completed_at").
+		Values(obj.Provider, obj.ExternalOrderID, obj.UserPhone, obj.UserName, obj.CompletedAt).
+		Suffix("ON CONFLICT (provider, external_order_id) DO NOTHING RETURNING id").
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := insert.ToSql()
+	if err != nil {
+		return "", false, err
+	}
+
+	var id string
+	err = tx.QueryRow(ctx, query, args...).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+
+	return id, true, nil
+}
+
 func (r *Repo) CreateBatch(ctx context.Context, objects []*model.Edit) error {
 
-	query := squirrel.Insert("ord").Columns("external_order_id", "user_phone", "user_name")
+	query := squirrel.Insert("ord").Columns("provider", "external_order_id", "user_phone", "user_name", "completed_at")
 
 	for _, obj := range objects {
-		query = query.Values(obj.ExternalOrderID, *obj.UserPhone, obj.UserName)
+		query = query.Values(obj.Provider, obj.ExternalOrderID, *obj.UserPhone, obj.UserName, obj.CompletedAt)
 	}
 
 	sql, args, err := query.PlaceholderFormat(squirrel.Dollar).ToSql()
@@ -201,6 +258,70 @@ func (r *Repo) CreateBatch(ctx context.Context, objects []*model.Edit) error {
 	return nil
 }
 
+// UpsertBatch inserts the orders, updating customer data of the ones already imported.
+func (r *Repo) UpsertBatch(ctx context.Context, objects []*model.Edit) error {
+
+	query := squirrel.Insert("ord").Columns("provider", "external_order_id", "user_phone", "user_name", "completed_at")
+
+	for _, obj := range objects {
+		query = query.Values(obj.Provider, obj.ExternalOrderID, *obj.UserPhone, obj.UserName, obj.CompletedAt)
+	}
+
+	query = query.Suffix("ON CONFLICT (provider, external_order_id) DO UPDATE SET user_phone = EXCLUDED.user_phone, user_name = EXCLUDED.user_name, " +
+		"completed_at = COALESCE(EXCLUDED.completed_at, ord.completed_at)")
+
+	sql, args, err := query.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	if _, err := r.Con.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("failed to execute batch upsert: %w", err)
+	}
+
+	return nil
+}
+
+// MarkItemsFetched records that the order items were fetched,
+// so an order without items is not fetched again.
+func (r *Repo) MarkItemsFetched(ctx context.Context, id string) error {
+	queryBuilder := squirrel.Update("ord").
+		Set("items_fetched_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"id": id})
+
+	sql, args, err := queryBuilder.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	if _, err = r.Con.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("failed to execute update: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateStatuses sets the status of the provider orders, returning the number of changed orders.
+func (r *Repo) UpdateStatuses(ctx context.Context, provider string, externalOrderIDs []string, status string) (int64, error) {
+	queryBuilder := squirrel.Update("ord").
+		Set("status", status).
+		Set("status_updated_at", squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{"provider": provider, "external_order_id": externalOrderIDs}).
+		Where(squirrel.NotEq{"status": status})
+
+	sql, args, err := queryBuilder.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	tag, err := r.Con.Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute update: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
 func (r *Repo) Update(ctx context.Context, pars *model.GetPars, obj *model.Edit) error {
 	if !pars.IsValid() {
 		return errs.InvalidInput
@@ -232,6 +353,10 @@ func (r *Repo) Delete(ctx context.Context, pars *model.GetPars) error {
 		queryBuilder = queryBuilder.Where(squirrel.Eq{"id": pars.ID})
 	}
 
+	if pars.Provider != "" {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"provider": pars.Provider})
+	}
+
 	if pars.ExternalOrderID != "" {
 		queryBuilder = queryBuilder.Where(squirrel.Eq{"external_order_id": pars.ExternalOrderID})
 	}
@@ -2,11 +2,14 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"github.com/jackc/pgx/v5"
 	"log/slog"
 	"mb-feedback/internal/domain/order/model"
 	"mb-feedback/internal/errs"
 	"strings"
+	"time"
 )
 
 type Service struct {
@@ -27,12 +30,16 @@ type RepoDBI interface {
 	ListOrdersNotInDetails(ctx context.Context, pars *model.ListPars) ([]*model.Order, error)
 	Create(ctx context.Context, obj *model.Edit) error
 	CreateBatch(ctx context.Context, objects []*model.Edit) error
+	UpsertBatch(ctx context.Context, objects []*model.Edit) error
+	CreateIfNotExistsTx(ctx context.Context, tx pgx.Tx, obj *model.Edit) (string, bool, error)
+	UpdateStatuses(ctx context.Context, provider string, externalOrderIDs []string, status string) (int64, error)
+	MarkItemsFetched(ctx context.Context, id string) error
 	Update(ctx context.Context, pars *model.GetPars, obj *model.Edit) error
 	Delete(ctx context.Context, pars *model.GetPars) error
 }
 
 type RepoFetcherI interface {
-	FetchOrders(ctx context.Context) ([]*model.Order, error)
+	FetchOrders(ctx context.Context, provider *model.Provider, completedAfter *time.Time) ([]*model.Order, error)
 }
 
 func (s *Service) list(ctx context.Context, pars *model.ListPars) ([]*model.Order, int64, error) {
@@ -45,10 +52,22 @@ func (s *Service) ListOrdersWithoutDetails(ctx context.Context, pars *model.List
 	return s.repoDB.ListOrdersNotInDetails(ctx, pars)
 }
 
+// MarkItemsFetched records that the order items were fetched, even if there were none,
+// so the order is no longer listed by ListOrdersWithoutDetails.
+func (s *Service) MarkItemsFetched(ctx context.Context, id string) error {
+	return s.repoDB.MarkItemsFetched(ctx, id)
+}
+
 func (s *Service) create(ctx context.Context, obj *model.Edit) error {
 	return s.repoDB.Create(ctx, obj)
 }
 
+// CreateIfNotExistsTx inserts the order within the transaction.
+// It returns the order ID and false if the order has already been imported.
+func (s *Service) CreateIfNotExistsTx(ctx context.Context, tx pgx.Tx, obj *model.Edit) (string, bool, error) {
+	return s.repoDB.CreateIfNotExistsTx(ctx, tx, obj)
+}
+
 func (s *Service) get(ctx context.Context, pars *model.GetPars, errNE bool) (*model.Order, bool, error) {
 	result, found, err := s.repoDB.Get(ctx, pars)
 	if err != nil {
@@ -72,14 +91,18 @@ func (s *Service) delete(ctx context.Context, pars *model.GetPars) error {
 	return s.repoDB.Delete(ctx, pars)
 }
 
-// FetchOrdersFromExternalSource fetch orders from external source, after insert them to DB.
-func (s *Service) FetchOrdersFromExternalSource(ctx context.Context) error {
-	fetchedOrders, err := s.repoFetcher.FetchOrders(ctx)
-	if err != nil {
-		return err
+// FetchOrdersFromExternalSource fetch provider orders completed after completedAfter from external source,
+// after insert the new ones to DB. It returns all fetched orders, so the caller can advance its sync cursor,
+// and the inserted ones with normalized phone numbers.
+// When the source cut the fetch short, the fetched orders are still inserted and
+// returned together with the errs.Truncated error, so the caller can tell how far it got.
+func (s *Service) FetchOrdersFromExternalSource(ctx context.Context, provider *model.Provider, completedAfter *time.Time) ([]*model.Order, []*model.Order, error) {
+	fetchedOrders, errFetch := s.repoFetcher.FetchOrders(ctx, provider, completedAfter)
+	if errFetch != nil && !errors.Is(errFetch, errs.Truncated) {
+		return nil, nil, errFetch
 	}
 	if len(fetchedOrders) == 0 {
-		return nil
+		return nil, nil, errFetch
 	}
 
 	externalOrderIDs := make([]string, len(fetchedOrders))
@@ -88,10 +111,11 @@ func (s *Service) FetchOrdersFromExternalSource(ctx context.Context) error {
 	}
 
 	existingOrders, _, err := s.repoDB.List(ctx, &model.ListPars{
+		Provider:         &provider.ID,
 		ExternalOrderIDs: &externalOrderIDs,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to fetch existing orders from DB: %w", err)
+		return nil, nil, fmt.Errorf("failed to fetch existing orders from DB: %w", err)
 	}
 
 	existingOrderMap := make(map[string]struct{}, len(existingOrders))
@@ -99,40 +123,129 @@ func (s *Service) FetchOrdersFromExternalSource(ctx context.Context) error {
 		existingOrderMap[order.ExternalOrderID] = struct{}{}
 	}
 
-	var ordersToInsert []*model.Edit
+	var (
+		ordersToInsert []*model.Edit
+		insertedOrders []*model.Order
+	)
 	for _, order := range fetchedOrders {
 		if _, exists := existingOrderMap[order.ExternalOrderID]; exists {
 			continue
 		}
 
 		var userPhone string
-		userPhone, err = s.formatPhoneNumber(order.UserPhone)
+		userPhone, err = s.FormatPhoneNumber(order.UserPhone)
 		if err != nil {
 			slog.Info("Failed to format phone number", "orderID", order.ExternalOrderID, "phoneNumber", order.UserPhone, "error", err.Error())
 			continue
 		}
 
 		ordersToInsert = append(ordersToInsert, &model.Edit{
+			Provider:        provider.ID,
 			ExternalOrderID: order.ExternalOrderID,
 			UserPhone:       &userPhone,
 			UserName:        &order.UserName,
+			CompletedAt:     completedAt(order),
 		})
+
+		inserted := *order
+		inserted.Provider = provider.ID
+		inserted.UserPhone = userPhone
+		insertedOrders = append(insertedOrders, &inserted)
+	}
+
+	if len(ordersToInsert) == 0 {
+		// the sync window overlaps the previous run, so everything may already be imported
+		slog.Info("No new orders to insert", "provider", provider.ID, "fetched", len(fetchedOrders))
+		return fetchedOrders, nil, errFetch
+	}
+
+	if err = s.repoDB.CreateBatch(ctx, ordersToInsert); err != nil {
+		return nil, nil, fmt.Errorf("failed to insert orders to DB: %w", err)
+	}
+
+	return fetchedOrders, insertedOrders, errFetch
+}
+
+// SyncStatusFromExternalSource fetches provider orders in the given status completed after completedAfter
+// and moves the already imported ones to that status. It returns the number of changed orders.
+// A truncated fetch still updates the fetched orders and returns the errs.Truncated error.
+func (s *Service) SyncStatusFromExternalSource(ctx context.Context, provider *model.Provider, status string, completedAfter *time.Time) (int64, error) {
+	statusProvider := *provider
+	statusProvider.Status = status
+
+	fetchedOrders, errFetch := s.repoFetcher.FetchOrders(ctx, &statusProvider, completedAfter)
+	if errFetch != nil && !errors.Is(errFetch, errs.Truncated) {
+		return 0, errFetch
+	}
+	if len(fetchedOrders) == 0 {
+		return 0, errFetch
+	}
+
+	externalOrderIDs := make([]string, len(fetchedOrders))
+	for i, order := range fetchedOrders {
+		externalOrderIDs[i] = order.ExternalOrderID
 	}
 
-	if len(ordersToInsert) > 0 {
-		if err = s.repoDB.CreateBatch(ctx, ordersToInsert); err != nil {
-			return fmt.Errorf("failed to insert orders to DB: %w", err)
+	changed, err := s.repoDB.UpdateStatuses(ctx, provider.ID, externalOrderIDs, status)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update order statuses: %w", err)
+	}
+
+	return changed, errFetch
+}
+
+// UpsertOrders inserts orders pushed by an external source, updating the ones already imported.
+// An order with an invalid phone number rejects the whole batch with errs.InvalidInput.
+// It returns the upserted orders with normalized phone numbers.
+func (s *Service) UpsertOrders(ctx context.Context, orders []*model.Order) ([]*model.Order, error) {
+	if len(orders) == 0 {
+		return nil, nil
+	}
+
+	ordersToUpsert := make([]*model.Edit, 0, len(orders))
+	upsertedOrders := make([]*model.Order, 0, len(orders))
+	for _, order := range orders {
+		if order.Provider == "" || order.ExternalOrderID == "" {
+			return nil, fmt.Errorf("%w: provider and external order id are required", errs.InvalidInput)
+		}
+
+		userPhone, err := s.FormatPhoneNumber(order.UserPhone)
+		if err != nil {
+			return nil, fmt.Errorf("%w: order %s: %s", errs.InvalidInput, order.ExternalOrderID, err.Error())
 		}
-	} else {
-		return fmt.Errorf("orders were missed trying to be added")
+
+		ordersToUpsert = append(ordersToUpsert, &model.Edit{
+			Provider:        order.Provider,
+			ExternalOrderID: order.ExternalOrderID,
+			UserPhone:       &userPhone,
+			UserName:        &order.UserName,
+			CompletedAt:     completedAt(order),
+		})
+
+		upserted := *order
+		upserted.UserPhone = userPhone
+		upsertedOrders = append(upsertedOrders, &upserted)
+	}
+
+	if err := s.repoDB.UpsertBatch(ctx, ordersToUpsert); err != nil {
+		return nil, fmt.Errorf("failed to upsert orders to DB: %w", err)
+	}
+
+	return upsertedOrders, nil
+}
+
+// completedAt returns the order completion time to store, nil when the source does not report it.
+func completedAt(order *model.Order) *time.Time {
+	if order.CompletedAt.IsZero() {
+		return nil
 	}
 
-	return nil
+	return &order.CompletedAt
 }
 
-// formatPhoneNumber accepts a phone number in various formats
+// FormatPhoneNumber accepts a phone number in various formats
 // and returns it in the format +77XXXXXXXXX.
-func (s *Service) formatPhoneNumber(phone string) (string, error) {
+func (s *Service) FormatPhoneNumber(phone string) (string, error) {
 	normalizedPhone := strings.TrimSpace(phone)
 
 	if len(normalizedPhone) == 10 {
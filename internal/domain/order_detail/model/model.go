@@ -6,15 +6,32 @@ type OrderDetail struct {
 	ID          string
 	OrderID     string
 	ProductCode string
+	ProductName string
+	Quantity    int
+	UnitPrice   float64
+	Category    string
+	MerchantSKU string
+	ScheduledAt time.Time
 	CreatedAt   time.Time
 }
 
 type OrderDetailWithUserInfo struct {
-	ID          string
-	OrderID     string
-	UserPhone   string
-	UserName    string
-	ProductCode string
+	ID            string
+	OrderID       string
+	OrderStatus   string
+	OrderProvider string
+	UserPhone     string
+	UserName      string
+	// UserLocale is the customer message language, empty when unknown
+	UserLocale string
+	// UserTimezone is the customer IANA timezone, empty when unknown
+	UserTimezone string
+	ProductCode  string
+	ProductName  string
+	Quantity     int
+	UnitPrice    float64
+	Category     string
+	MerchantSKU  string
 }
 
 type GetPars struct {
@@ -34,12 +51,27 @@ type ListPars struct {
 	OrderIDs      *[]string
 	ProductCode   *string
 	ProductCodes  *[]string
+	Category      *string
+	Categories    *[]string
 	CreatedBefore *time.Time
 	CreatedAfter  *time.Time
+	// ScheduledBefore and ScheduledAfter filter details by the time feedback is requested
+	ScheduledBefore *time.Time
+	ScheduledAfter  *time.Time
+	// IgnoredNotificationStatuses are notification statuses that do not count
+	// when looking for details without a notification
+	IgnoredNotificationStatuses *[]string
 }
 
 type Edit struct {
 	ID          string
 	OrderID     string
 	ProductCode *string
+	ProductName *string
+	Quantity    *int
+	UnitPrice   *float64
+	Category    *string
+	MerchantSKU *string
+	// ScheduledAt is when feedback on the product is requested, nil means right away
+	ScheduledAt *time.Time
 }
@@ -2,21 +2,22 @@ package fetcher
 
 import (
 	"context"
-	mb_broker "mb-feedback/internal/client/fetcher/mb-broker"
+	"mb-feedback/internal/client/fetcher"
+	orderDetailModel "mb-feedback/internal/domain/order_detail/model"
 )
 
 type Repo struct {
-	client *mb_broker.Client
+	client fetcher.Fetcher
 }
 
-func New(client *mb_broker.Client) *Repo {
+func New(client fetcher.Fetcher) *Repo {
 	return &Repo{
 		client: client,
 	}
 }
 
-func (r *Repo) FetchProductCodes(ctx context.Context, externalOrderID string) ([]string, error) {
-	result, err := r.client.FetchProductCodes(ctx, externalOrderID)
+func (r *Repo) FetchItems(ctx context.Context, provider, externalOrderID string) ([]*orderDetailModel.OrderDetail, error) {
+	result, err := r.client.FetchOrderItems(ctx, provider, externalOrderID)
 	if err != nil {
 		return nil, err
 	}
@@ -28,7 +28,9 @@ func (r *Repo) Get(ctx context.Context, pars *model.GetPars) (*model.OrderDetail
 
 	var result model.OrderDetail
 
-	queryBuilder := squirrel.Select("*").From("ord_detail")
+	queryBuilder := squirrel.
+		Select("id", "order_id", "product_code", "product_name", "quantity", "unit_price", "category", "merchant_sku", "COALESCE(scheduled_at, created_at)", "created_at").
+		From("ord_detail")
 
 	if len(pars.ID) != 0 {
 		queryBuilder = queryBuilder.Where(squirrel.Eq{"id": pars.ID})
@@ -49,7 +51,9 @@ func (r *Repo) Get(ctx context.Context, pars *model.GetPars) (*model.OrderDetail
 		return nil, false, err
 	}
 
-	err = r.Con.QueryRow(ctx, sql, args...).Scan(&result.ID, &result.OrderID, &result.ProductCode, &result.CreatedAt)
+	err = r.Con.QueryRow(ctx, sql, args...).Scan(
+		&result.ID, &result.OrderID, &result.ProductCode, &result.ProductName, &result.Quantity,
+		&result.UnitPrice, &result.Category, &result.MerchantSKU, &result.ScheduledAt, &result.CreatedAt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, false, nil
@@ -62,7 +66,7 @@ func (r *Repo) Get(ctx context.Context, pars *model.GetPars) (*model.OrderDetail
 
 func (r *Repo) List(ctx context.Context, pars *model.ListPars) ([]*model.OrderDetail, int64, error) {
 	queryBuilder := squirrel.
-		Select("id", "order_id", "product_code", "created_at").
+		Select("id", "order_id", "product_code", "product_name", "quantity", "unit_price", "category", "merchant_sku", "COALESCE(scheduled_at, created_at)", "created_at").
 		From("ord_detail")
 
 	if pars.ID != nil {
@@ -89,6 +93,14 @@ func (r *Repo) List(ctx context.Context, pars *model.ListPars) ([]*model.OrderDe
 		queryBuilder = queryBuilder.Where(squirrel.Eq{"product_code": pars.ProductCodes})
 	}
 
+	if pars.Category != nil {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"category": pars.Category})
+	}
+
+	if pars.Categories != nil {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"category": pars.Categories})
+	}
+
 	if pars.CreatedBefore != nil {
 		queryBuilder = queryBuilder.Where(squirrel.LtOrEq{"created_at": pars.CreatedBefore})
 	}
@@ -97,6 +109,14 @@ func (r *Repo) List(ctx context.Context, pars *model.ListPars) ([]*model.OrderDe
 		queryBuilder = queryBuilder.Where(squirrel.GtOrEq{"created_at": pars.CreatedAfter})
 	}
 
+	if pars.ScheduledBefore != nil {
+		queryBuilder = queryBuilder.Where(squirrel.LtOrEq{"scheduled_at": pars.ScheduledBefore})
+	}
+
+	if pars.ScheduledAfter != nil {
+		queryBuilder = queryBuilder.Where(squirrel.GtOrEq{"scheduled_at": pars.ScheduledAfter})
+	}
+
 	sql, args, err := queryBuilder.PlaceholderFormat(squirrel.Dollar).ToSql()
 	if err != nil {
 		return nil, 0, err
@@ -111,7 +131,9 @@ func (r *Repo) List(ctx context.Context, pars *model.ListPars) ([]*model.OrderDe
 	var result []*model.OrderDetail
 	for rows.Next() {
 		var data model.OrderDetail
-		err = rows.Scan(&data.ID, &data.OrderID, &data.ProductCode, &data.CreatedAt)
+		err = rows.Scan(
+			&data.ID, &data.OrderID, &data.ProductCode, &data.ProductName, &data.Quantity,
+			&data.UnitPrice, &data.Category, &data.MerchantSKU, &data.ScheduledAt, &data.CreatedAt)
 		if err != nil {
 			return nil, 0, err
 		}
@@ -126,22 +148,69 @@ func (r *Repo) List(ctx context.Context, pars *model.ListPars) ([]*model.OrderDe
 }
 
 func (r *Repo) ListDetailNotInNotification(ctx context.Context, pars *model.ListPars) ([]*model.OrderDetailWithUserInfo, error) {
+	queryBuilder := detailWithUserInfoQuery(pars)
+
+	if pars.IgnoredNotificationStatuses != nil {
+		queryBuilder = queryBuilder.Where(
+			"NOT EXISTS (SELECT 1 FROM notification n WHERE n.order_item_id = od.id AND n.status <> ALL(?))",
+			*pars.IgnoredNotificationStatuses)
+	} else {
+		queryBuilder = queryBuilder.Where("NOT EXISTS (SELECT 1 FROM notification n WHERE n.order_item_id = od.id)")
+	}
+
+	return r.listDetailWithUserInfo(ctx, queryBuilder)
+}
+
+// ListDetailWithUserInfo returns details with the order and customer data needed to notify about them.
+func (r *Repo) ListDetailWithUserInfo(ctx context.Context, pars *model.ListPars) ([]*model.OrderDetailWithUserInfo, error) {
+	return r.listDetailWithUserInfo(ctx, detailWithUserInfoQuery(pars))
+}
+
+// detailWithUserInfoQuery selects details with their order and customer. Product data
+// stored empty, e.g. before the product was added to the catalog, is taken from the catalog.
+func detailWithUserInfoQuery(pars *model.ListPars) squirrel.SelectBuilder {
 	queryBuilder := squirrel.
 		Select(
 			"od.id AS order_detail_id",
 			"od.product_code",
+			"COALESCE(NULLIF(od.product_name, ''), p.product_name, '')",
+			"od.quantity",
+			"COALESCE(NULLIF(od.unit_price, 0), p.unit_price, 0)",
+			"COALESCE(NULLIF(od.category, ''), p.category, '')",
+			"COALESCE(NULLIF(od.merchant_sku, ''), p.merchant_sku, '')",
 			"o.external_order_id AS order_id",
+			"o.status",
+			"o.provider",
 			"o.user_phone",
 			"o.user_name",
+			"COALESCE(c.locale, '')",
+			"COALESCE(c.timezone, '')",
 		).
 		From("ord_detail od").
 		LeftJoin("ord o ON od.order_id = o.id").
-		Where("NOT EXISTS (SELECT 1 FROM notification n WHERE n.order_item_id = od.id)")
+		LeftJoin("customer c ON c.id = o.customer_id").
+		LeftJoin("product p ON p.product_code = od.product_code")
+
+	if pars.IDs != nil {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"od.id": *pars.IDs})
+	}
 
 	if pars.CreatedAfter != nil {
 		queryBuilder = queryBuilder.Where(squirrel.GtOrEq{"od.created_at": pars.CreatedAfter})
 	}
 
+	if pars.ScheduledBefore != nil {
+		queryBuilder = queryBuilder.Where(squirrel.LtOrEq{"od.scheduled_at": pars.ScheduledBefore})
+	}
+
+	if pars.ScheduledAfter != nil {
+		queryBuilder = queryBuilder.Where(squirrel.GtOrEq{"od.scheduled_at": pars.ScheduledAfter})
+	}
+
+	return queryBuilder
+}
+
+func (r *Repo) listDetailWithUserInfo(ctx context.Context, queryBuilder squirrel.SelectBuilder) ([]*model.OrderDetailWithUserInfo, error) {
 	sql, args, err := queryBuilder.PlaceholderFormat(squirrel.Dollar).ToSql()
 	if err != nil {
 		return nil, fmt.Errorf("failed to build query: %w", err)
@@ -156,7 +225,10 @@ func (r *Repo) ListDetailNotInNotification(ctx context.Context, pars *model.List
 	var results []*model.OrderDetailWithUserInfo
 	for rows.Next() {
 		var detail model.OrderDetailWithUserInfo
-		if err := rows.Scan(&detail.ID, &detail.ProductCode, &detail.OrderID, &detail.UserPhone, &detail.UserName); err != nil {
+		if err := rows.Scan(
+			&detail.ID, &detail.ProductCode, &detail.ProductName, &detail.Quantity, &detail.UnitPrice,
+			&detail.Category, &detail.MerchantSKU, &detail.OrderID, &detail.OrderStatus, &detail.OrderProvider,
+			&detail.UserPhone, &detail.UserName, &detail.UserLocale, &detail.UserTimezone); err != nil {
 			return nil, fmt.Errorf("failed to scan row: %w", err)
 		}
 		results = append(results, &detail)
@@ -167,8 +239,8 @@ func (r *Repo) ListDetailNotInNotification(ctx context.Context, pars *model.List
 
 func (r *Repo) Create(ctx context.Context, obj *model.Edit) error {
 	insert := squirrel.Insert("ord_detail").
-		Columns("order_id", "product_code").
-		Values(obj.OrderID, obj.ProductCode).
+		Columns("order_id", "product_code", "product_name", "quantity", "unit_price", "category", "merchant_sku", "scheduled_at").
+		Values(obj.OrderID, obj.ProductCode, obj.ProductName, obj.Quantity, obj.UnitPrice, obj.Category, obj.MerchantSKU, scheduledAt(obj)).
 		PlaceholderFormat(squirrel.Dollar)
 
 	query, args, err := insert.ToSql()
@@ -185,10 +257,11 @@ func (r *Repo) Create(ctx context.Context, obj *model.Edit) error {
 
 func (r *Repo) CreateBatch(ctx context.Context, objects []*model.Edit) error {
 
-	query := squirrel.Insert("ord_detail").Columns("order_id", "product_code")
+	query := squirrel.Insert("ord_detail").
+		Columns("order_id", "product_code", "product_name", "quantity", "unit_price", "category", "merchant_sku", "scheduled_at")
 
 	for _, obj := range objects {
-		query = query.Values(obj.OrderID, *obj.ProductCode)
+		query = query.Values(obj.OrderID, *obj.ProductCode, obj.ProductName, obj.Quantity, obj.UnitPrice, obj.Category, obj.MerchantSKU, scheduledAt(obj))
 	}
 
 	sql, args, err := query.PlaceholderFormat(squirrel.Dollar).ToSql()
@@ -203,6 +276,36 @@ func (r *Repo) CreateBatch(ctx context.Context, objects []*model.Edit) error {
 	return nil
 }
 
+func (r *Repo) CreateBatchTx(ctx context.Context, tx pgx.Tx, objects []*model.Edit) error {
+
+	query := squirrel.Insert("ord_detail").
+		Columns("order_id", "product_code", "product_name", "quantity", "unit_price", "category", "merchant_sku", "scheduled_at")
+
+	for _, obj := range objects {
+		query = query.Values(obj.OrderID, *obj.ProductCode, obj.ProductName, obj.Quantity, obj.UnitPrice, obj.Category, obj.MerchantSKU, scheduledAt(obj))
+	}
+
+	sql, args, err := query.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	if _, err := tx.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("failed to execute batch insert: %w", err)
+	}
+
+	return nil
+}
+
+// scheduledAt returns the value stored as the detail feedback time, the insert time if it is not set.
+func scheduledAt(obj *model.Edit) any {
+	if obj.ScheduledAt == nil {
+		return squirrel.Expr("NOW()")
+	}
+
+	return obj.ScheduledAt
+}
+
 func (r *Repo) Update(ctx context.Context, pars *model.GetPars, obj *model.Edit) error {
 	if !pars.IsValid() {
 		return errs.InvalidInput
@@ -212,7 +315,34 @@ func (r *Repo) Update(ctx context.Context, pars *model.GetPars, obj *model.Edit)
 
 	if obj.ProductCode != nil {
 		queryBuilder = queryBuilder.Set("product_code", obj.ProductCode)
-	} else {
+	}
+
+	if obj.ProductName != nil {
+		queryBuilder = queryBuilder.Set("product_name", obj.ProductName)
+	}
+
+	if obj.Quantity != nil {
+		queryBuilder = queryBuilder.Set("quantity", obj.Quantity)
+	}
+
+	if obj.UnitPrice != nil {
+		queryBuilder = queryBuilder.Set("unit_price", obj.UnitPrice)
+	}
+
+	if obj.Category != nil {
+		queryBuilder = queryBuilder.Set("category", obj.Category)
+	}
+
+	if obj.MerchantSKU != nil {
+		queryBuilder = queryBuilder.Set("merchant_sku", obj.MerchantSKU)
+	}
+
+	if obj.ScheduledAt != nil {
+		queryBuilder = queryBuilder.Set("scheduled_at", obj.ScheduledAt)
+	}
+
+	if obj.ProductCode == nil && obj.ProductName == nil && obj.Quantity == nil &&
+		obj.UnitPrice == nil && obj.Category == nil && obj.MerchantSKU == nil && obj.ScheduledAt == nil {
 		return nil
 	}
 
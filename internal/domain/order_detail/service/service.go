@@ -24,8 +24,10 @@ type RepoDBI interface {
 	Get(ctx context.Context, pars *model.GetPars) (*model.OrderDetail, bool, error)
 	List(ctx context.Context, pars *model.ListPars) ([]*model.OrderDetail, int64, error)
 	ListDetailNotInNotification(ctx context.Context, pars *model.ListPars) ([]*model.OrderDetailWithUserInfo, error)
+	ListDetailWithUserInfo(ctx context.Context, pars *model.ListPars) ([]*model.OrderDetailWithUserInfo, error)
 	Create(ctx context.Context, obj *model.Edit) error
 	CreateBatch(ctx context.Context, objects []*model.Edit) error
+	CreateBatchTx(ctx context.Context, tx pgx.Tx, objects []*model.Edit) error
 	Update(ctx context.Context, pars *model.GetPars, obj *model.Edit) error
 	Delete(ctx context.Context, pars *model.GetPars) error
 	BeginTx(ctx context.Context) (pgx.Tx, error)
@@ -33,7 +35,7 @@ type RepoDBI interface {
 }
 
 type RepoFetcherI interface {
-	FetchProductCodes(ctx context.Context, externalOrderID string) ([]string, error)
+	FetchItems(ctx context.Context, provider, externalOrderID string) ([]*model.OrderDetail, error)
 }
 
 func (s *Service) list(ctx context.Context, pars *model.ListPars) ([]*model.OrderDetail, int64, error) {
@@ -46,6 +48,11 @@ func (s *Service) ListDetailWithoutNotification(ctx context.Context, pars *model
 	return s.repoDB.ListDetailNotInNotification(ctx, pars)
 }
 
+// ListDetailWithUserInfo retrieves OrderDetails with UserInfo regardless of their notifications.
+func (s *Service) ListDetailWithUserInfo(ctx context.Context, pars *model.ListPars) ([]*model.OrderDetailWithUserInfo, error) {
+	return s.repoDB.ListDetailWithUserInfo(ctx, pars)
+}
+
 func (s *Service) create(ctx context.Context, obj *model.Edit) error {
 	return s.repoDB.Create(ctx, obj)
 }
@@ -54,6 +61,18 @@ func (s *Service) CreateList(ctx context.Context, objs []*model.Edit) error {
 	return s.repoDB.CreateBatch(ctx, objs)
 }
 
+func (s *Service) CreateListTx(ctx context.Context, tx pgx.Tx, objs []*model.Edit) error {
+	return s.repoDB.CreateBatchTx(ctx, tx, objs)
+}
+
+func (s *Service) BeginTx(ctx context.Context) (pgx.Tx, error) {
+	return s.repoDB.BeginTx(ctx)
+}
+
+func (s *Service) HandleTxCompletion(tx pgx.Tx, err *error) {
+	s.repoDB.HandleTxCompletion(tx, err)
+}
+
 func (s *Service) get(ctx context.Context, pars *model.GetPars, errNE bool) (*model.OrderDetail, bool, error) {
 	result, found, err := s.repoDB.Get(ctx, pars)
 	if err != nil {
@@ -77,6 +96,7 @@ func (s *Service) delete(ctx context.Context, pars *model.GetPars) error {
 	return s.repoDB.Delete(ctx, pars)
 }
 
-func (s *Service) FetchProductCodesByOrder(ctx context.Context, externalOrderID string) ([]string, error) {
-	return s.repoFetcher.FetchProductCodes(ctx, externalOrderID)
+// FetchItemsByOrder fetches the provider order line items from the external source.
+func (s *Service) FetchItemsByOrder(ctx context.Context, provider, externalOrderID string) ([]*model.OrderDetail, error) {
+	return s.repoFetcher.FetchItems(ctx, provider, externalOrderID)
 }
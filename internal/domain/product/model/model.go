@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// Product is a catalog entry with the product data mb-broker does not return:
+// it only lists the product codes of an order.
+type Product struct {
+	ProductCode string
+	ProductName string
+	UnitPrice   float64
+	Category    string
+	MerchantSKU string
+	UpdatedAt   time.Time
+}
+
+type GetPars struct {
+	ProductCode string
+}
+
+func (m *GetPars) IsValid() bool {
+	return m.ProductCode != ""
+}
+
+type ListPars struct {
+	ProductCodes *[]string
+	Category     *string
+}
+
+type Edit struct {
+	ProductCode string
+	ProductName string
+	UnitPrice   float64
+	Category    string
+	MerchantSKU string
+}
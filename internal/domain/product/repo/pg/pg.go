@@ -0,0 +1,106 @@
+package pg
+
+import (
+	"context"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"mb-feedback/internal/domain/product/model"
+	"mb-feedback/internal/errs"
+)
+
+type Repo struct {
+	Con *pgxpool.Pool
+}
+
+func New(con *pgxpool.Pool) *Repo {
+	return &Repo{
+		con,
+	}
+}
+
+func (r *Repo) List(ctx context.Context, pars *model.ListPars) ([]*model.Product, int64, error) {
+	queryBuilder := squirrel.
+		Select("product_code", "product_name", "unit_price", "category", "merchant_sku", "updated_at").
+		From("product").
+		OrderBy("product_code")
+
+	if pars.ProductCodes != nil {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"product_code": *pars.ProductCodes})
+	}
+
+	if pars.Category != nil {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"category": pars.Category})
+	}
+
+	sql, args, err := queryBuilder.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.Con.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var result []*model.Product
+	for rows.Next() {
+		var data model.Product
+		if err = rows.Scan(&data.ProductCode, &data.ProductName, &data.UnitPrice, &data.Category, &data.MerchantSKU, &data.UpdatedAt); err != nil {
+			return nil, 0, err
+		}
+
+		result = append(result, &data)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return result, int64(len(result)), nil
+}
+
+// UpsertBatch adds the products to the catalog, replacing the data of known product codes.
+func (r *Repo) UpsertBatch(ctx context.Context, objects []*model.Edit) error {
+	query := squirrel.Insert("product").Columns("product_code", "product_name", "unit_price", "category", "merchant_sku")
+
+	for _, obj := range objects {
+		query = query.Values(obj.ProductCode, obj.ProductName, obj.UnitPrice, obj.Category, obj.MerchantSKU)
+	}
+
+	query = query.Suffix("ON CONFLICT (product_code) DO UPDATE SET " +
+		"product_name = EXCLUDED.product_name, unit_price = EXCLUDED.unit_price, category = EXCLUDED.category, " +
+		"merchant_sku = EXCLUDED.merchant_sku, updated_at = NOW()")
+
+	sql, args, err := query.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	if _, err := r.Con.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("failed to execute batch upsert: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the product from the catalog, returning false if it was not there.
+func (r *Repo) Delete(ctx context.Context, pars *model.GetPars) (bool, error) {
+	if !pars.IsValid() {
+		return false, errs.InvalidInput
+	}
+
+	queryBuilder := squirrel.Delete("product").Where(squirrel.Eq{"product_code": pars.ProductCode})
+
+	sql, args, err := queryBuilder.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return false, err
+	}
+
+	tag, err := r.Con.Exec(ctx, sql, args...)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"mb-feedback/internal/domain/product/model"
+	"mb-feedback/internal/errs"
+	"strings"
+)
+
+// batchSize keeps the number of query parameters of one upsert below the Postgres limit.
+const batchSize = 1000
+
+type Service struct {
+	repoDB RepoDBI
+}
+
+func New(repoDB RepoDBI) *Service {
+	return &Service{
+		repoDB: repoDB,
+	}
+}
+
+type RepoDBI interface {
+	List(ctx context.Context, pars *model.ListPars) ([]*model.Product, int64, error)
+	UpsertBatch(ctx context.Context, objects []*model.Edit) error
+	Delete(ctx context.Context, pars *model.GetPars) (bool, error)
+}
+
+func (s *Service) List(ctx context.Context, pars *model.ListPars) ([]*model.Product, int64, error) {
+	return s.repoDB.List(ctx, pars)
+}
+
+// Catalog returns the catalog entries of the product codes by product code.
+// Codes missing from the catalog are missing from the result.
+func (s *Service) Catalog(ctx context.Context, productCodes []string) (map[string]*model.Product, error) {
+	if len(productCodes) == 0 {
+		return map[string]*model.Product{}, nil
+	}
+
+	products, _, err := s.repoDB.List(ctx, &model.ListPars{ProductCodes: &productCodes})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list products: %w", err)
+	}
+
+	result := make(map[string]*model.Product, len(products))
+	for _, product := range products {
+		result[product.ProductCode] = product
+	}
+
+	return result, nil
+}
+
+// SetList adds the products to the catalog or replaces them. A product code must not repeat in objs.
+func (s *Service) SetList(ctx context.Context, objs []*model.Edit) error {
+	for _, obj := range objs {
+		obj.ProductCode = strings.TrimSpace(obj.ProductCode)
+		obj.Category = strings.TrimSpace(obj.Category)
+		if obj.ProductCode == "" {
+			return fmt.Errorf("%w: product_code is required", errs.InvalidInput)
+		}
+		if obj.UnitPrice < 0 {
+			return fmt.Errorf("%w: unit_price of %s must not be negative", errs.InvalidInput, obj.ProductCode)
+		}
+	}
+
+	for start := 0; start < len(objs); start += batchSize {
+		if err := s.repoDB.UpsertBatch(ctx, objs[start:min(start+batchSize, len(objs))]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Delete removes the product from the catalog.
+func (s *Service) Delete(ctx context.Context, pars *model.GetPars) error {
+	deleted, err := s.repoDB.Delete(ctx, pars)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return errs.ObjectNotFound
+	}
+
+	return nil
+}
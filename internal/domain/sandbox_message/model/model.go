@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+// SandboxMessage is a Voximplant request rendered but not sent in the sandbox notifier mode.
+type SandboxMessage struct {
+	ID          string
+	PhoneNumber string
+	OrderID     string
+	ProductCode string
+	TemplateID  string
+	Method      string
+	URL         string
+	Body        string
+	Reason      string
+	CreatedAt   time.Time
+}
+
+type ListPars struct {
+	PhoneNumber *string
+	OrderID     *string
+	// Limit caps the number of the most recently created messages returned
+	Limit *uint64
+}
+
+type Edit struct {
+	PhoneNumber string
+	OrderID     string
+	ProductCode string
+	TemplateID  string
+	Method      string
+	URL         string
+	Body        string
+	Reason      string
+}
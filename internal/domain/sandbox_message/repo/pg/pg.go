@@ -0,0 +1,87 @@
+package pg
+
+import (
+	"context"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"mb-feedback/internal/domain/sandbox_message/model"
+)
+
+type Repo struct {
+	Con *pgxpool.Pool
+}
+
+func New(con *pgxpool.Pool) *Repo {
+	return &Repo{
+		con,
+	}
+}
+
+func (r *Repo) List(ctx context.Context, pars *model.ListPars) ([]*model.SandboxMessage, int64, error) {
+	queryBuilder := squirrel.
+		Select("id", "phone_number", "order_id", "product_code", "template_id", "method", "url", "body", "reason", "created_at").
+		From("sandbox_message").
+		OrderBy("created_at DESC", "id DESC")
+
+	if pars.PhoneNumber != nil {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"phone_number": pars.PhoneNumber})
+	}
+
+	if pars.OrderID != nil {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"order_id": pars.OrderID})
+	}
+
+	if pars.Limit != nil {
+		queryBuilder = queryBuilder.Limit(*pars.Limit)
+	}
+
+	sql, args, err := queryBuilder.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.Con.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var result []*model.SandboxMessage
+	for rows.Next() {
+		var data model.SandboxMessage
+		err = rows.Scan(
+			&data.ID, &data.PhoneNumber, &data.OrderID, &data.ProductCode, &data.TemplateID,
+			&data.Method, &data.URL, &data.Body, &data.Reason, &data.CreatedAt)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		result = append(result, &data)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return result, int64(len(result)), nil
+}
+
+// Create stores the message and returns its ID.
+func (r *Repo) Create(ctx context.Context, obj *model.Edit) (string, error) {
+	insert := squirrel.Insert("sandbox_message").
+		Columns("phone_number", "order_id", "product_code", "template_id", "method", "url", "body", "reason").
+		Values(obj.PhoneNumber, obj.OrderID, obj.ProductCode, obj.TemplateID, obj.Method, obj.URL, obj.Body, obj.Reason).
+		Suffix("RETURNING id").
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := insert.ToSql()
+	if err != nil {
+		return "", err
+	}
+
+	var id string
+	if err = r.Con.QueryRow(ctx, query, args...).Scan(&id); err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
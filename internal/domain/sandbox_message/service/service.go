@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+	"mb-feedback/internal/domain/sandbox_message/model"
+)
+
+type Service struct {
+	repoDB RepoDBI
+}
+
+func New(repoDB RepoDBI) *Service {
+	return &Service{
+		repoDB: repoDB,
+	}
+}
+
+type RepoDBI interface {
+	List(ctx context.Context, pars *model.ListPars) ([]*model.SandboxMessage, int64, error)
+	Create(ctx context.Context, obj *model.Edit) (string, error)
+}
+
+func (s *Service) List(ctx context.Context, pars *model.ListPars) ([]*model.SandboxMessage, int64, error) {
+	return s.repoDB.List(ctx, pars)
+}
+
+func (s *Service) Create(ctx context.Context, obj *model.Edit) (string, error) {
+	return s.repoDB.Create(ctx, obj)
+}
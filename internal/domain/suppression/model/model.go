@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// Suppression is a phone number that must not receive messages.
+type Suppression struct {
+	Phone     string
+	Reason    string
+	Source    string
+	CreatedAt time.Time
+}
+
+type GetPars struct {
+	Phone string
+}
+
+func (m *GetPars) IsValid() bool {
+	return m.Phone != ""
+}
+
+type ListPars struct {
+	Phones *[]string
+	Source *string
+}
+
+type Edit struct {
+	Phone  string
+	Reason *string
+	Source string
+}
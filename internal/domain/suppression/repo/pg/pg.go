@@ -0,0 +1,136 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"mb-feedback/internal/domain/suppression/model"
+	"mb-feedback/internal/errs"
+)
+
+type Repo struct {
+	Con *pgxpool.Pool
+}
+
+func New(con *pgxpool.Pool) *Repo {
+	return &Repo{
+		con,
+	}
+}
+
+func (r *Repo) Get(ctx context.Context, pars *model.GetPars) (*model.Suppression, bool, error) {
+	if !pars.IsValid() {
+		return nil, false, errs.InvalidInput
+	}
+
+	var result model.Suppression
+
+	queryBuilder := squirrel.
+		Select("phone", "COALESCE(reason, '')", "source", "created_at").
+		From("suppression").
+		Where(squirrel.Eq{"phone": pars.Phone}).
+		Limit(1)
+
+	sql, args, err := queryBuilder.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return nil, false, err
+	}
+
+	err = r.Con.QueryRow(ctx, sql, args...).Scan(&result.Phone, &result.Reason, &result.Source, &result.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	return &result, true, nil
+}
+
+func (r *Repo) List(ctx context.Context, pars *model.ListPars) ([]*model.Suppression, int64, error) {
+	queryBuilder := squirrel.
+		Select("phone", "COALESCE(reason, '')", "source", "created_at").
+		From("suppression").
+		OrderBy("created_at DESC", "phone")
+
+	if pars.Phones != nil {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"phone": *pars.Phones})
+	}
+
+	if pars.Source != nil {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"source": pars.Source})
+	}
+
+	sql, args, err := queryBuilder.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.Con.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var result []*model.Suppression
+	for rows.Next() {
+		var data model.Suppression
+		if err = rows.Scan(&data.Phone, &data.Reason, &data.Source, &data.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+
+		result = append(result, &data)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return result, int64(len(result)), nil
+}
+
+// UpsertBatch adds the phone numbers to the list. Numbers already on the list keep
+// their original time, only the reason and source are replaced.
+func (r *Repo) UpsertBatch(ctx context.Context, objects []*model.Edit) error {
+	query := squirrel.Insert("suppression").Columns("phone", "reason", "source")
+
+	for _, obj := range objects {
+		query = query.Values(obj.Phone, obj.Reason, obj.Source)
+	}
+
+	query = query.Suffix("ON CONFLICT (phone) DO UPDATE SET reason = EXCLUDED.reason, source = EXCLUDED.source")
+
+	sql, args, err := query.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build query: %w", err)
+	}
+
+	if _, err := r.Con.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("failed to execute batch upsert: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes the phone number from the list, returning false if it was not there.
+func (r *Repo) Delete(ctx context.Context, pars *model.GetPars) (bool, error) {
+	if !pars.IsValid() {
+		return false, errs.InvalidInput
+	}
+
+	queryBuilder := squirrel.Delete("suppression").Where(squirrel.Eq{"phone": pars.Phone})
+
+	sql, args, err := queryBuilder.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return false, err
+	}
+
+	tag, err := r.Con.Exec(ctx, sql, args...)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"mb-feedback/internal/domain/suppression/model"
+	"mb-feedback/internal/errs"
+)
+
+// batchSize keeps a single insert well below the Postgres limit of bind parameters.
+const batchSize = 1000
+
+type Service struct {
+	repoDB RepoDBI
+}
+
+func New(repoDB RepoDBI) *Service {
+	return &Service{
+		repoDB: repoDB,
+	}
+}
+
+type RepoDBI interface {
+	Get(ctx context.Context, pars *model.GetPars) (*model.Suppression, bool, error)
+	List(ctx context.Context, pars *model.ListPars) ([]*model.Suppression, int64, error)
+	UpsertBatch(ctx context.Context, objects []*model.Edit) error
+	Delete(ctx context.Context, pars *model.GetPars) (bool, error)
+}
+
+func (s *Service) Get(ctx context.Context, pars *model.GetPars, errNE bool) (*model.Suppression, bool, error) {
+	result, found, err := s.repoDB.Get(ctx, pars)
+	if err != nil {
+		return nil, false, fmt.Errorf("repoDb.Get: %w", err)
+	}
+	if !found {
+		if errNE {
+			return nil, false, errs.ObjectNotFound
+		}
+		return nil, false, nil
+	}
+
+	return result, found, nil
+}
+
+func (s *Service) List(ctx context.Context, pars *model.ListPars) ([]*model.Suppression, int64, error) {
+	return s.repoDB.List(ctx, pars)
+}
+
+// AddList adds the phone numbers to the suppression list. A phone number must not repeat in objs.
+func (s *Service) AddList(ctx context.Context, objs []*model.Edit) error {
+	for start := 0; start < len(objs); start += batchSize {
+		if err := s.repoDB.UpsertBatch(ctx, objs[start:min(start+batchSize, len(objs))]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Remove takes the phone number off the suppression list.
+func (s *Service) Remove(ctx context.Context, pars *model.GetPars) error {
+	removed, err := s.repoDB.Delete(ctx, pars)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return errs.ObjectNotFound
+	}
+
+	return nil
+}
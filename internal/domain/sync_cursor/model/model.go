@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+type SyncCursor struct {
+	Provider    string
+	LastTs      time.Time
+	LastOrderID string
+	UpdatedAt   time.Time
+}
+
+type GetPars struct {
+	Provider string
+}
+
+func (m *GetPars) IsValid() bool {
+	return m.Provider != ""
+}
+
+type ListPars struct {
+	Provider  *string
+	Providers *[]string
+}
+
+type Edit struct {
+	Provider    string
+	LastTs      *time.Time
+	LastOrderID *string
+}
@@ -0,0 +1,134 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"mb-feedback/internal/domain/sync_cursor/model"
+	"mb-feedback/internal/errs"
+)
+
+type Repo struct {
+	Con *pgxpool.Pool
+}
+
+func New(con *pgxpool.Pool) *Repo {
+	return &Repo{
+		con,
+	}
+}
+
+func (r *Repo) Get(ctx context.Context, pars *model.GetPars) (*model.SyncCursor, bool, error) {
+	if !pars.IsValid() {
+		return nil, false, errs.InvalidInput
+	}
+
+	var result model.SyncCursor
+
+	queryBuilder := squirrel.
+		Select("provider", "last_ts", "last_order_id", "updated_at").
+		From("sync_cursor").
+		Where(squirrel.Eq{"provider": pars.Provider}).
+		Limit(1)
+
+	sql, args, err := queryBuilder.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return nil, false, err
+	}
+
+	err = r.Con.QueryRow(ctx, sql, args...).Scan(&result.Provider, &result.LastTs, &result.LastOrderID, &result.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	return &result, true, nil
+}
+
+func (r *Repo) List(ctx context.Context, pars *model.ListPars) ([]*model.SyncCursor, int64, error) {
+	queryBuilder := squirrel.
+		Select("provider", "last_ts", "last_order_id", "updated_at").
+		From("sync_cursor").
+		OrderBy("provider")
+
+	if pars.Provider != nil {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"provider": pars.Provider})
+	}
+
+	if pars.Providers != nil {
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"provider": pars.Providers})
+	}
+
+	sql, args, err := queryBuilder.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.Con.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var result []*model.SyncCursor
+	for rows.Next() {
+		var data model.SyncCursor
+		err = rows.Scan(&data.Provider, &data.LastTs, &data.LastOrderID, &data.UpdatedAt)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		result = append(result, &data)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return result, int64(len(result)), nil
+}
+
+// Upsert creates the cursor for the provider or moves the existing one to the given position.
+func (r *Repo) Upsert(ctx context.Context, obj *model.Edit) error {
+	if obj.Provider == "" || obj.LastTs == nil {
+		return errs.InvalidInput
+	}
+
+	lastOrderID := ""
+	if obj.LastOrderID != nil {
+		lastOrderID = *obj.LastOrderID
+	}
+
+	insert := squirrel.Insert("sync_cursor").
+		Columns("provider", "last_ts", "last_order_id").
+		Values(obj.Provider, obj.LastTs, lastOrderID).
+		Suffix("ON CONFLICT (provider) DO UPDATE SET last_ts = EXCLUDED.last_ts, last_order_id = EXCLUDED.last_order_id, updated_at = NOW()").
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := insert.ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.Con.Exec(ctx, query, args...)
+	return err
+}
+
+func (r *Repo) Delete(ctx context.Context, pars *model.GetPars) error {
+	if !pars.IsValid() {
+		return errs.InvalidInput
+	}
+
+	queryBuilder := squirrel.Delete("sync_cursor").Where(squirrel.Eq{"provider": pars.Provider})
+
+	sql, args, err := queryBuilder.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.Con.Exec(ctx, sql, args...)
+	return err
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"mb-feedback/internal/domain/sync_cursor/model"
+	"mb-feedback/internal/errs"
+)
+
+type Service struct {
+	repoDB RepoDBI
+}
+
+func New(repoDB RepoDBI) *Service {
+	return &Service{
+		repoDB: repoDB,
+	}
+}
+
+type RepoDBI interface {
+	Get(ctx context.Context, pars *model.GetPars) (*model.SyncCursor, bool, error)
+	List(ctx context.Context, pars *model.ListPars) ([]*model.SyncCursor, int64, error)
+	Upsert(ctx context.Context, obj *model.Edit) error
+	Delete(ctx context.Context, pars *model.GetPars) error
+}
+
+func (s *Service) List(ctx context.Context, pars *model.ListPars) ([]*model.SyncCursor, int64, error) {
+	return s.repoDB.List(ctx, pars)
+}
+
+func (s *Service) Get(ctx context.Context, pars *model.GetPars, errNE bool) (*model.SyncCursor, bool, error) {
+	result, found, err := s.repoDB.Get(ctx, pars)
+	if err != nil {
+		return nil, false, fmt.Errorf("repoDb.Get: %w", err)
+	}
+	if !found {
+		if errNE {
+			return nil, false, errs.ObjectNotFound
+		}
+		return nil, false, nil
+	}
+
+	return result, found, nil
+}
+
+// Set moves the provider cursor to the given position, creating it if needed.
+func (s *Service) Set(ctx context.Context, obj *model.Edit) error {
+	return s.repoDB.Upsert(ctx, obj)
+}
+
+// Reset removes the provider cursor, so the next import starts from scratch.
+func (s *Service) Reset(ctx context.Context, pars *model.GetPars) error {
+	return s.repoDB.Delete(ctx, pars)
+}
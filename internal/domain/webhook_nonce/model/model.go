@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+// WebhookNonce is a nonce of a signed webhook request, kept until its timestamp
+// leaves the tolerance window so that the request cannot be replayed.
+type WebhookNonce struct {
+	Nonce     string
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+type Edit struct {
+	Nonce     string
+	ExpiresAt time.Time
+}
@@ -0,0 +1,58 @@
+package pg
+
+import (
+	"context"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"mb-feedback/internal/domain/webhook_nonce/model"
+	"mb-feedback/internal/errs"
+	"time"
+)
+
+type Repo struct {
+	Con *pgxpool.Pool
+}
+
+func New(con *pgxpool.Pool) *Repo {
+	return &Repo{
+		con,
+	}
+}
+
+// Create stores the nonce and reports false if it is already stored.
+func (r *Repo) Create(ctx context.Context, obj *model.Edit) (bool, error) {
+	if obj.Nonce == "" {
+		return false, errs.InvalidInput
+	}
+
+	insert := squirrel.Insert("webhook_nonce").
+		Columns("nonce", "expires_at").
+		Values(obj.Nonce, obj.ExpiresAt).
+		Suffix("ON CONFLICT (nonce) DO NOTHING").
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := insert.ToSql()
+	if err != nil {
+		return false, err
+	}
+
+	tag, err := r.Con.Exec(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// DeleteExpired removes nonces that expired before the given time.
+func (r *Repo) DeleteExpired(ctx context.Context, before time.Time) error {
+	queryBuilder := squirrel.Delete("webhook_nonce").Where(squirrel.Lt{"expires_at": before})
+
+	sql, args, err := queryBuilder.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = r.Con.Exec(ctx, sql, args...)
+	return err
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"mb-feedback/internal/domain/webhook_nonce/model"
+	"time"
+)
+
+type Service struct {
+	repoDB RepoDBI
+}
+
+func New(repoDB RepoDBI) *Service {
+	return &Service{
+		repoDB: repoDB,
+	}
+}
+
+type RepoDBI interface {
+	Create(ctx context.Context, obj *model.Edit) (bool, error)
+	DeleteExpired(ctx context.Context, before time.Time) error
+}
+
+// Use remembers the nonce until expiresAt and reports false if it has already been used.
+// The nonces are shared by all instances, so a request is accepted once whichever instance gets it.
+func (s *Service) Use(ctx context.Context, nonce string, expiresAt time.Time) (bool, error) {
+	if err := s.repoDB.DeleteExpired(ctx, time.Now()); err != nil {
+		return false, fmt.Errorf("failed to delete expired nonces: %w", err)
+	}
+
+	created, err := s.repoDB.Create(ctx, &model.Edit{Nonce: nonce, ExpiresAt: expiresAt})
+	if err != nil {
+		return false, fmt.Errorf("failed to store nonce: %w", err)
+	}
+
+	return created, nil
+}
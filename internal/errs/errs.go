@@ -14,7 +14,13 @@ func (e Err) Error() string {
 }
 
 const (
-	InvalidInput   = Err("invalid_input")
-	BadStatusCode  = Err("bad_status_code")
-	ObjectNotFound = Err("object_not_found")
+	InvalidInput     = Err("invalid_input")
+	BadStatusCode    = Err("bad_status_code")
+	ObjectNotFound   = Err("object_not_found")
+	CircuitOpen      = Err("circuit_open")
+	Unauthorized     = Err("unauthorized")
+	InvalidResponse  = Err("invalid_response")
+	Transient        = Err("transient")
+	Truncated        = Err("truncated")
+	TokenUnavailable = Err("token_unavailable")
 )
@@ -0,0 +1,136 @@
+package fakebroker
+
+import (
+	"fmt"
+	"math/rand/v2"
+	mb_broker "mb-feedback/internal/client/fetcher/mb-broker"
+	"mb-feedback/internal/cns"
+	"strings"
+	"time"
+)
+
+// Kazakhstan mobile operator prefixes (Beeline, Kcell/Activ, Tele2/Altel)
+var phonePrefixes = []string{
+	"700", "701", "702", "705", "707", "708", "747", "771", "775", "776", "777", "778",
+}
+
+var femaleNames = []string{
+	"Айгерим", "Асель", "Дана", "Жанар", "Камила", "Мадина", "Алия", "Гульнара", "Томирис", "Сабина",
+	"Ольга", "Екатерина",
+}
+
+var maleNames = []string{
+	"Айдос", "Арман", "Ержан", "Нурлан", "Данияр", "Бауыржан", "Ерлан", "Санжар", "Тимур", "Алихан",
+	"Дмитрий", "Сергей",
+}
+
+var lastNames = []string{
+	"Ахметов", "Жумабаев", "Серикбаев", "Нурпеисов", "Касымов", "Сулейменов", "Абенов", "Омаров",
+	"Исмаилов", "Бекмуханов", "Тулегенов", "Искаков", "Иванов", "Ким",
+}
+
+// malformedPhones are phones mb-broker is known to send for badly filled profiles
+var malformedPhones = []string{
+	"", "12345", "+7 (701) 123", "8 701 123 45 67 доб. 2", "not-a-phone", "+4915112345678",
+}
+
+// order is a generated mb-broker order together with its product codes.
+type order struct {
+	prvID        string
+	ord          mb_broker.OrdSt
+	productCodes []string
+}
+
+// generator produces synthetic orders. It is not safe for concurrent use.
+type generator struct {
+	rnd *rand.Rand
+	cfg Config
+	seq int
+}
+
+func newGenerator(cfg Config) *generator {
+	return &generator{
+		rnd: rand.New(rand.NewPCG(cfg.Seed, cfg.Seed)),
+		cfg: cfg,
+	}
+}
+
+func (g *generator) order(prvID string, completedAt time.Time) *order {
+	g.seq++
+
+	firstName, lastName := g.pick(maleNames), g.pick(lastNames)
+	if g.rnd.IntN(2) == 0 {
+		firstName = g.pick(femaleNames)
+		if strings.HasSuffix(lastName, "ов") || strings.HasSuffix(lastName, "ев") {
+			lastName += "а"
+		}
+	}
+
+	result := &order{
+		prvID: prvID,
+		ord: mb_broker.OrdSt{
+			PrvCode:      fmt.Sprintf("%d%06d", 5000+g.rnd.IntN(5000), g.seq),
+			Status:       cns.OrderStatusCompleted,
+			CompletionTs: completedAt.UTC().Truncate(time.Second),
+			Customer: mb_broker.OrdCustomerSt{
+				CellPhone: g.phone(),
+				FirstName: firstName,
+				LastName:  lastName,
+			},
+		},
+	}
+
+	if g.rnd.Float64() < 0.3 {
+		result.ord.Customer.Email = fmt.Sprintf("%s.%d@mail.kz", strings.ToLower(transliterate(lastName)), g.rnd.IntN(1000))
+	}
+
+	if g.rnd.Float64() < g.cfg.EmptyItemsRate {
+		return result
+	}
+
+	count := 1 + g.rnd.IntN(3)
+	for i := 0; i < count; i++ {
+		result.productCodes = append(result.productCodes, fmt.Sprintf("%d", 100000000+g.rnd.IntN(900000000)))
+	}
+
+	return result
+}
+
+// phone returns a Kazakh mobile number in one of the formats mb-broker uses,
+// or a malformed one with the configured probability.
+func (g *generator) phone() string {
+	if g.rnd.Float64() < g.cfg.BadPhoneRate {
+		return g.pick(malformedPhones)
+	}
+
+	number := g.pick(phonePrefixes) + fmt.Sprintf("%07d", g.rnd.IntN(10000000))
+	if g.rnd.IntN(2) == 0 {
+		return "7" + number
+	}
+
+	return number
+}
+
+func (g *generator) pick(values []string) string {
+	return values[g.rnd.IntN(len(values))]
+}
+
+var translit = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ж': "zh", 'з': "z", 'и': "i",
+	'й': "i", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s",
+	'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'ы': "y", 'э': "e",
+	'ю': "yu", 'я': "ya",
+}
+
+func transliterate(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if v, ok := translit[r]; ok {
+			b.WriteString(v)
+		} else if r < 128 {
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
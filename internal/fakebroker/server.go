@@ -0,0 +1,312 @@
+// Package fakebroker implements a local stand-in for mb-broker serving synthetic
+// orders, so the service can be developed without touching the real one.
+// It serves the endpoints used by the mb-broker client and can inject the
+// failures seen in production: malformed phones, orders without items and 5xx errors.
+package fakebroker
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	mathRand "math/rand/v2"
+	mb_broker "mb-feedback/internal/client/fetcher/mb-broker"
+	"mb-feedback/internal/cns"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const tokenTTL = time.Hour
+
+type Config struct {
+	// Providers are the prv_id values orders are generated for.
+	Providers []string
+	// Rate is the number of new orders generated per minute for every provider.
+	Rate float64
+	// Initial is the number of orders per provider generated at start,
+	// spread over the last History.
+	Initial int
+	History time.Duration
+	Seed    uint64
+
+	// BadPhoneRate is the share of orders with a malformed customer phone.
+	BadPhoneRate float64
+	// EmptyItemsRate is the share of orders without line items.
+	EmptyItemsRate float64
+	// ErrorRate is the share of requests answered with 503.
+	ErrorRate float64
+
+	// Token is the accepted static bearer token, empty disables auth checks.
+	// Tokens issued by /oauth/token are accepted as well.
+	Token string
+}
+
+type Server struct {
+	cfg Config
+
+	mu     sync.Mutex
+	gen    *generator
+	orders []*order
+	tokens map[string]time.Time
+}
+
+func New(cfg Config) *Server {
+	s := &Server{
+		cfg:    cfg,
+		gen:    newGenerator(cfg),
+		tokens: make(map[string]time.Time),
+	}
+
+	now := time.Now()
+	for _, prvID := range cfg.Providers {
+		for i := 0; i < cfg.Initial; i++ {
+			completedAt := now.Add(-time.Duration(s.gen.rnd.Int64N(int64(cfg.History) + 1)))
+			s.orders = append(s.orders, s.gen.order(prvID, completedAt))
+		}
+	}
+	s.sortOrders()
+
+	return s
+}
+
+func (s *Server) Handler() http.Handler {
+	httpMux := http.NewServeMux()
+
+	httpMux.HandleFunc("POST /oauth/token", s.TokenHandler)
+	httpMux.HandleFunc("GET /ord", s.authorized(s.OrdersHandler))
+	httpMux.HandleFunc("POST /ord/product_codes", s.authorized(s.ProductCodesHandler))
+
+	return httpMux
+}
+
+// Generate adds new orders at the configured rate until ctx is done.
+func (s *Server) Generate(ctx context.Context) {
+	if s.cfg.Rate <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(float64(time.Minute) / s.cfg.Rate))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		s.mu.Lock()
+		for _, prvID := range s.cfg.Providers {
+			o := s.gen.order(prvID, time.Now())
+			s.orders = append(s.orders, o)
+
+			slog.Info("Order generated", "provider", prvID, "prvCode", o.ord.PrvCode, "phone", o.ord.Customer.CellPhone, "products", len(o.productCodes))
+		}
+		s.mu.Unlock()
+	}
+}
+
+// OrdersHandler serves completed orders page by page, oldest first.
+// With ordering=completion_ts they are sorted by completion time explicitly.
+func (s *Server) OrdersHandler(w http.ResponseWriter, r *http.Request) {
+	if s.injectError(w) {
+		return
+	}
+
+	query := r.URL.Query()
+
+	page, _ := strconv.Atoi(query.Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	pageSize, _ := strconv.Atoi(query.Get("page_size"))
+	if pageSize < 1 {
+		pageSize = 100
+	}
+
+	status := query.Get("status")
+	if status == "" {
+		status = cns.OrderStatusCompleted
+	}
+
+	var completedAfter time.Time
+	if v := query.Get("completion_ts_gte"); v != "" {
+		var err error
+		if completedAfter, err = time.Parse(time.RFC3339, v); err != nil {
+			writeError(w, r, http.StatusBadRequest, "invalid_params", "completion_ts_gte must be RFC3339")
+			return
+		}
+	}
+
+	s.mu.Lock()
+	matched := make([]mb_broker.OrdSt, 0)
+	for _, o := range s.orders {
+		if o.prvID != query.Get("prv_id") || o.ord.Status != status || o.ord.CompletionTs.Before(completedAfter) {
+			continue
+		}
+		matched = append(matched, o.ord)
+	}
+	s.mu.Unlock()
+
+	switch ordering := query.Get("ordering"); ordering {
+	case "":
+	case "completion_ts":
+		slices.SortStableFunc(matched, func(a, b mb_broker.OrdSt) int {
+			return a.CompletionTs.Compare(b.CompletionTs)
+		})
+	default:
+		writeError(w, r, http.StatusBadRequest, "invalid_params", "unsupported ordering "+ordering)
+		return
+	}
+
+	repObj := &mb_broker.FetchCompletedOrdersRepSt{
+		Page:       page,
+		PageSize:   pageSize,
+		TotalCount: len(matched),
+		Results:    []mb_broker.OrdSt{},
+	}
+	if from := (page - 1) * pageSize; from < len(matched) {
+		repObj.Results = matched[from:min(from+pageSize, len(matched))]
+	}
+
+	writeJSON(w, repObj)
+}
+
+// ProductCodesHandler serves the product codes of an order.
+func (s *Server) ProductCodesHandler(w http.ResponseWriter, r *http.Request) {
+	if s.injectError(w) {
+		return
+	}
+
+	reqObj := &mb_broker.FetchProductCodesReqSt{}
+	if err := json.NewDecoder(r.Body).Decode(reqObj); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid_body", err.Error())
+		return
+	}
+
+	o := s.findOrder(reqObj.PrvCode)
+	if o == nil {
+		writeError(w, r, http.StatusNotFound, "order_not_found", "order "+reqObj.PrvCode+" not found")
+		return
+	}
+
+	result := o.productCodes
+	if result == nil {
+		result = []string{}
+	}
+
+	writeJSON(w, result)
+}
+
+// TokenHandler issues tokens with the OAuth2 client-credentials grant.
+// Any client credentials are accepted.
+func (s *Server) TokenHandler(w http.ResponseWriter, r *http.Request) {
+	if r.FormValue("grant_type") != "client_credentials" {
+		writeError(w, r, http.StatusBadRequest, "unsupported_grant_type", "only client_credentials is supported")
+		return
+	}
+
+	token := randomHex(16)
+
+	s.mu.Lock()
+	s.tokens[token] = time.Now().Add(tokenTTL)
+	s.mu.Unlock()
+
+	writeJSON(w, map[string]any{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_in":   int(tokenTTL.Seconds()),
+	})
+}
+
+func (s *Server) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.cfg.Token == "" {
+			next(w, r)
+			return
+		}
+
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		s.mu.Lock()
+		expiresAt, issued := s.tokens[token]
+		s.mu.Unlock()
+
+		if token != s.cfg.Token && (!issued || time.Now().After(expiresAt)) {
+			writeError(w, r, http.StatusUnauthorized, "invalid_token", "token is missing, invalid or expired")
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+// injectError answers with 503 with the configured probability.
+func (s *Server) injectError(w http.ResponseWriter) bool {
+	if s.cfg.ErrorRate <= 0 || mathRand.Float64() >= s.cfg.ErrorRate {
+		return false
+	}
+
+	w.Header().Set("Retry-After", "1")
+	writeError(w, nil, http.StatusServiceUnavailable, "unavailable", "injected failure")
+
+	return true
+}
+
+func (s *Server) findOrder(prvCode string) *order {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, o := range s.orders {
+		if o.ord.PrvCode == prvCode {
+			return o
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) sortOrders() {
+	slices.SortFunc(s.orders, func(a, b *order) int {
+		if c := a.ord.CompletionTs.Compare(b.ord.CompletionTs); c != 0 {
+			return c
+		}
+		return strings.Compare(a.ord.PrvCode, b.ord.PrvCode)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, obj any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(obj); err != nil {
+		slog.Error("Failed to write response", "error", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, statusCode int, code, message string) {
+	requestID := randomHex(8)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Request-Id", requestID)
+	w.WriteHeader(statusCode)
+
+	_ = json.NewEncoder(w).Encode(&mb_broker.ErrorRepSt{
+		Code:      code,
+		Message:   message,
+		RequestID: requestID,
+	})
+
+	if r != nil {
+		slog.Warn("Request rejected", "path", r.URL.Path, "statusCode", statusCode, "code", code)
+	}
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
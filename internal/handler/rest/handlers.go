@@ -2,8 +2,34 @@ package rest
 
 import (
 	"context"
+	"encoding/json"
+	"io"
 	"log/slog"
+	"mb-feedback/internal/client/breaker"
+	mb_broker "mb-feedback/internal/client/fetcher/mb-broker"
+	"mb-feedback/internal/client/notifier/voximplant"
+	customerModel "mb-feedback/internal/domain/customer/model"
+	feedbackDelayModel "mb-feedback/internal/domain/feedback_delay/model"
+	messageTemplateModel "mb-feedback/internal/domain/message_template/model"
+	notificationModel "mb-feedback/internal/domain/notification/model"
+	orderModel "mb-feedback/internal/domain/order/model"
+	productModel "mb-feedback/internal/domain/product/model"
+	suppressionModel "mb-feedback/internal/domain/suppression/model"
+	"mb-feedback/internal/errs"
+	notificationUsecase "mb-feedback/internal/usecase/notification"
 	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	maxWebhookBodySize = 1 << 20
+	maxImportBodySize  = 32 << 20
+
+	defaultListLimit = 100
+	maxListLimit     = 1000
 )
 
 // FetchOrdersHandler handles updating the list of orders
@@ -35,11 +61,15 @@ func (s *Rest) GetProductCodesHandler(w http.ResponseWriter, r *http.Request) {
 
 		s.getProductCodeMutex.Lock()
 		defer s.getProductCodeMutex.Unlock()
-		err := s.orderDetailUsc.FetchProductCodes(context.Background())
+		summary, err := s.orderDetailUsc.FetchProductCodes(context.Background())
 		if err != nil {
-			slog.Error("Error fetching product codes: ", err)
+			slog.Error("Error fetching product codes: ", "error", err)
 		} else {
-			slog.Info("Fetched product codes")
+			slog.Info("Fetched product codes",
+				"total", summary.Total,
+				"succeeded", summary.Succeeded,
+				"failed", summary.Failed,
+				"failures", summary.Failures)
 		}
 	}()
 }
@@ -56,9 +86,574 @@ func (s *Rest) SendNotificationHandler(w http.ResponseWriter, r *http.Request) {
 		defer s.sendNotificationMutex.Unlock()
 		err := s.notificationUsc.SendNotification(context.Background())
 		if err != nil {
-			slog.Error("Error sending notification: ", err)
+			slog.Error("Error sending notification: ", "error", err)
 		} else {
 			slog.Info("Sent notification")
 		}
 	}()
 }
+
+// OrderWebhookHandler handles order-completed events pushed by mb-broker
+func (s *Rest) OrderWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodySize))
+	if err != nil {
+		writeError(w, errs.InvalidInput)
+		return
+	}
+
+	if err = s.webhookVerifier.verify(r, body); err != nil {
+		slog.Warn("Rejected order webhook", "error", err)
+		writeError(w, err)
+		return
+	}
+
+	event := &mb_broker.OrderEventSt{}
+	if err = json.Unmarshal(body, event); err != nil {
+		writeError(w, errs.InvalidInput)
+		return
+	}
+
+	if event.Event != mb_broker.EventOrderCompleted {
+		slog.Info("Ignored order webhook event", "event", event.Event)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if err = event.Order.Validate(); err != nil {
+		slog.Error("Rejected order webhook", "provider", event.PrvID, "error", err)
+		writeError(w, errs.InvalidInput)
+		return
+	}
+	if event.Order.Customer.CellPhone == "" {
+		slog.Warn("Order webhook without customer phone", "provider", event.PrvID, "orderID", event.Order.PrvCode)
+	}
+
+	order := event.Order.ToOrder(event.PrvID)
+	if err = s.orderUsc.IngestOrders(r.Context(), []*orderModel.Order{order}); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	slog.Info("Order received from webhook", "provider", order.Provider, "orderID", order.ExternalOrderID)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// VoximplantCallbackHandler handles message delivery and read status callbacks from Voximplant
+func (s *Rest) VoximplantCallbackHandler(w http.ResponseWriter, r *http.Request) {
+	if err := verifyToken(r, s.callbackToken); err != nil {
+		slog.Warn("Rejected Voximplant callback", "error", err)
+		writeError(w, err)
+		return
+	}
+
+	reqObj := &voximplant.StatusCallbackSt{}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxWebhookBodySize)).Decode(reqObj); err != nil {
+		writeError(w, errs.InvalidInput)
+		return
+	}
+
+	events := make([]*notificationUsecase.DeliveryEvent, 0, len(reqObj.Events))
+	for _, v := range reqObj.Events {
+		status := v.DeliveryStatus()
+		if status == "" || v.MessageID == "" {
+			slog.Info("Ignored Voximplant callback event", "messageID", v.MessageID, "status", v.Status)
+			continue
+		}
+
+		events = append(events, &notificationUsecase.DeliveryEvent{
+			ProviderMessageID: v.MessageID,
+			Status:            status,
+			Ts:                v.Time(),
+		})
+	}
+
+	applied, err := s.notificationUsc.HandleDeliveryEvents(r.Context(), events)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, &DeliveryCallbackRepSt{
+		Received: len(reqObj.Events),
+		Applied:  applied,
+	})
+}
+
+// ListNotificationsHandler lists the most recent notifications with their delivery status.
+// Filters: status (comma separated), phone, order_item_id, provider_message_id, limit.
+func (s *Rest) ListNotificationsHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limit := uint64(defaultListLimit)
+	if v := query.Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.ParseUint(v, 10, 64); err != nil || limit == 0 || limit > maxListLimit {
+			writeError(w, errs.InvalidInput)
+			return
+		}
+	}
+
+	pars := &notificationModel.ListPars{Limit: &limit}
+	if v := query.Get("status"); v != "" {
+		statuses := strings.Split(strings.ToUpper(v), ",")
+		pars.Statuses = &statuses
+	}
+	if v := query.Get("phone"); v != "" {
+		pars.PhoneNumber = &v
+	}
+	if v := query.Get("order_item_id"); v != "" {
+		pars.OrderItemID = &v
+	}
+	if v := query.Get("provider_message_id"); v != "" {
+		pars.ProviderMessageID = &v
+	}
+
+	notifications, err := s.notificationUsc.ListNotifications(r.Context(), pars)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	result := make([]*NotificationRepSt, 0, len(notifications))
+	for _, v := range notifications {
+		result = append(result, &NotificationRepSt{
+			ID:                v.ID,
+			OrderItemID:       v.OrderItemID,
+			PhoneNumber:       v.PhoneNumber,
+			Status:            v.Status,
+			Reason:            v.Reason,
+			ProviderMessageID: v.ProviderMessageID,
+			ResponseCode:      v.ResponseCode,
+			SentAt:            v.SentAt,
+			DeliveredAt:       v.DeliveredAt,
+			ReadAt:            v.ReadAt,
+			UndeliveredAt:     v.UndeliveredAt,
+			AttemptCount:      v.AttemptCount,
+			NextAttemptAt:     v.NextAttemptAt,
+			LastError:         v.LastError,
+			CreatedAt:         v.CreatedAt,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// ListMessageTemplatesHandler lists the message templates picked by customer locale, provider and category
+func (s *Rest) ListMessageTemplatesHandler(w http.ResponseWriter, r *http.Request) {
+	templates, err := s.notificationUsc.ListMessageTemplates(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	result := make([]*MessageTemplateRepSt, 0, len(templates))
+	for _, v := range templates {
+		result = append(result, &MessageTemplateRepSt{
+			ID:         v.ID,
+			Locale:     v.Locale,
+			Provider:   v.Provider,
+			Category:   v.Category,
+			TemplateID: v.TemplateID,
+			Params:     v.Params,
+			UpdatedAt:  v.UpdatedAt,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// SetMessageTemplateHandler creates or replaces the message template for its locale, provider and category
+func (s *Rest) SetMessageTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	reqObj := &MessageTemplateReqSt{}
+	if err := json.NewDecoder(r.Body).Decode(reqObj); err != nil {
+		writeError(w, errs.InvalidInput)
+		return
+	}
+
+	id, err := s.notificationUsc.SetMessageTemplate(r.Context(), &messageTemplateModel.Edit{
+		Locale:     reqObj.Locale,
+		Provider:   reqObj.Provider,
+		Category:   reqObj.Category,
+		TemplateID: reqObj.TemplateID,
+		Params:     reqObj.Params,
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, &IDRepSt{ID: id})
+}
+
+// DeleteMessageTemplateHandler removes a message template
+func (s *Rest) DeleteMessageTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	if err := s.notificationUsc.DeleteMessageTemplate(r.Context(), r.PathValue("id")); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListFeedbackDelaysHandler lists how long after the order completion feedback is requested by product category
+func (s *Rest) ListFeedbackDelaysHandler(w http.ResponseWriter, r *http.Request) {
+	delays, err := s.orderDetailUsc.ListFeedbackDelays(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	result := make([]*FeedbackDelayRepSt, 0, len(delays))
+	for _, v := range delays {
+		result = append(result, &FeedbackDelayRepSt{
+			Category:     v.Category,
+			Delay:        v.Delay.String(),
+			DelaySeconds: int64(v.Delay / time.Second),
+			UpdatedAt:    v.UpdatedAt,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// SetFeedbackDelayHandler sets the feedback delay of a product category
+func (s *Rest) SetFeedbackDelayHandler(w http.ResponseWriter, r *http.Request) {
+	reqObj := &FeedbackDelayReqSt{}
+	if err := json.NewDecoder(r.Body).Decode(reqObj); err != nil {
+		writeError(w, errs.InvalidInput)
+		return
+	}
+
+	delay, err := parseDelay(reqObj.Delay)
+	if err != nil {
+		writeError(w, errs.InvalidInput)
+		return
+	}
+
+	err = s.orderDetailUsc.SetFeedbackDelay(r.Context(), &feedbackDelayModel.Edit{
+		Category: reqObj.Category,
+		Delay:    delay,
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteFeedbackDelayHandler removes the feedback delay of the product category given in the category query parameter
+func (s *Rest) DeleteFeedbackDelayHandler(w http.ResponseWriter, r *http.Request) {
+	if err := s.orderDetailUsc.DeleteFeedbackDelay(r.Context(), r.URL.Query().Get("category")); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListProductsHandler lists the product catalog
+func (s *Rest) ListProductsHandler(w http.ResponseWriter, r *http.Request) {
+	products, err := s.orderDetailUsc.ListProducts(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	result := make([]*ProductRepSt, 0, len(products))
+	for _, v := range products {
+		result = append(result, &ProductRepSt{
+			ProductCode: v.ProductCode,
+			ProductName: v.ProductName,
+			UnitPrice:   v.UnitPrice,
+			Category:    v.Category,
+			MerchantSKU: v.MerchantSKU,
+			UpdatedAt:   v.UpdatedAt,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// SetProductsHandler adds a list of products to the catalog or replaces them
+func (s *Rest) SetProductsHandler(w http.ResponseWriter, r *http.Request) {
+	var reqObj []*ProductReqSt
+	if err := json.NewDecoder(r.Body).Decode(&reqObj); err != nil {
+		writeError(w, errs.InvalidInput)
+		return
+	}
+
+	objs := make([]*productModel.Edit, 0, len(reqObj))
+	for _, v := range reqObj {
+		objs = append(objs, &productModel.Edit{
+			ProductCode: v.ProductCode,
+			ProductName: v.ProductName,
+			UnitPrice:   v.UnitPrice,
+			Category:    v.Category,
+			MerchantSKU: v.MerchantSKU,
+		})
+	}
+
+	if err := s.orderDetailUsc.SetProducts(r.Context(), objs); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DeleteProductHandler removes a product from the catalog
+func (s *Rest) DeleteProductHandler(w http.ResponseWriter, r *http.Request) {
+	if err := s.orderDetailUsc.DeleteProduct(r.Context(), r.PathValue("code")); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseDelay parses a Go duration or a whole number of days such as "14d".
+func parseDelay(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	return time.ParseDuration(value)
+}
+
+// ImportOrdersHandler imports orders from an uploaded CSV or JSONL file.
+// The file is sent either as the raw body or as the "file" field of a multipart form;
+// the format is taken from the "format" query parameter or the file extension.
+func (s *Rest) ImportOrdersHandler(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBodySize)
+
+	format := r.URL.Query().Get("format")
+	provider := r.URL.Query().Get("provider")
+	if provider == "" {
+		provider = s.importProvider
+	}
+
+	var body io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		f, header, err := r.FormFile("file")
+		if err != nil {
+			writeError(w, errs.InvalidInput)
+			return
+		}
+		defer f.Close()
+
+		body = f
+		if format == "" {
+			format = strings.TrimPrefix(strings.ToLower(filepath.Ext(header.Filename)), ".")
+		}
+	}
+
+	report, err := s.orderImportUsc.Import(r.Context(), provider, body, format)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+// AddSuppressionHandler puts a phone number on the suppression list, so it gets no more messages
+func (s *Rest) AddSuppressionHandler(w http.ResponseWriter, r *http.Request) {
+	reqObj := &SuppressionReqSt{}
+	if err := json.NewDecoder(r.Body).Decode(reqObj); err != nil {
+		writeError(w, errs.InvalidInput)
+		return
+	}
+
+	suppression, err := s.suppressionUsc.Add(r.Context(), reqObj.Phone, reqObj.Reason, reqObj.Source)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newSuppressionRepSt(suppression))
+}
+
+// GetSuppressionHandler looks up a phone number on the suppression list
+func (s *Rest) GetSuppressionHandler(w http.ResponseWriter, r *http.Request) {
+	suppression, err := s.suppressionUsc.Lookup(r.Context(), r.PathValue("phone"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newSuppressionRepSt(suppression))
+}
+
+// DeleteSuppressionHandler takes a phone number off the suppression list
+func (s *Rest) DeleteSuppressionHandler(w http.ResponseWriter, r *http.Request) {
+	if err := s.suppressionUsc.Remove(r.Context(), r.PathValue("phone")); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ImportSuppressionsHandler puts phone numbers from an uploaded CSV file on the suppression list.
+// The file is sent either as the raw body or as the "file" field of a multipart form.
+func (s *Rest) ImportSuppressionsHandler(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBodySize)
+
+	var body io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		f, _, err := r.FormFile("file")
+		if err != nil {
+			writeError(w, errs.InvalidInput)
+			return
+		}
+		defer f.Close()
+
+		body = f
+	}
+
+	report, err := s.suppressionUsc.Import(r.Context(), body)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
+
+func newSuppressionRepSt(suppression *suppressionModel.Suppression) *SuppressionRepSt {
+	return &SuppressionRepSt{
+		Phone:     suppression.Phone,
+		Reason:    suppression.Reason,
+		Source:    suppression.Source,
+		CreatedAt: suppression.CreatedAt,
+	}
+}
+
+// GetCustomerHandler returns the customer profile of a phone number
+func (s *Rest) GetCustomerHandler(w http.ResponseWriter, r *http.Request) {
+	customer, err := s.customerUsc.Get(r.Context(), r.PathValue("phone"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newCustomerRepSt(customer))
+}
+
+// UpdateCustomerHandler sets the locale and timezone of a customer
+func (s *Rest) UpdateCustomerHandler(w http.ResponseWriter, r *http.Request) {
+	reqObj := &CustomerReqSt{}
+	if err := json.NewDecoder(r.Body).Decode(reqObj); err != nil {
+		writeError(w, errs.InvalidInput)
+		return
+	}
+
+	customer, err := s.customerUsc.SetPreferences(r.Context(), r.PathValue("phone"), reqObj.Locale, reqObj.Timezone)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, newCustomerRepSt(customer))
+}
+
+func newCustomerRepSt(customer *customerModel.Customer) *CustomerRepSt {
+	return &CustomerRepSt{
+		Phone:       customer.Phone,
+		FirstName:   customer.FirstName,
+		LastName:    customer.LastName,
+		Email:       customer.Email,
+		Locale:      customer.Locale,
+		Timezone:    customer.Timezone,
+		FirstSeenAt: customer.FirstSeenAt,
+		LastSeenAt:  customer.LastSeenAt,
+		OrderCount:  customer.OrderCount,
+	}
+}
+
+// ListSyncCursorsHandler returns the positions of order import sync cursors
+func (s *Rest) ListSyncCursorsHandler(w http.ResponseWriter, r *http.Request) {
+	cursors, err := s.orderUsc.ListSyncCursors(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	result := make([]*SyncCursorRepSt, 0, len(cursors))
+	for _, cursor := range cursors {
+		result = append(result, &SyncCursorRepSt{
+			Provider:    cursor.Provider,
+			LastTs:      cursor.LastTs,
+			LastOrderID: cursor.LastOrderID,
+			UpdatedAt:   cursor.UpdatedAt,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// RewindSyncCursorHandler moves the sync cursor of a provider to the given position
+func (s *Rest) RewindSyncCursorHandler(w http.ResponseWriter, r *http.Request) {
+	reqObj := &SyncCursorRewindReqSt{}
+	if err := json.NewDecoder(r.Body).Decode(reqObj); err != nil || reqObj.LastTs.IsZero() {
+		writeError(w, errs.InvalidInput)
+		return
+	}
+
+	s.updateOrderMutex.Lock()
+	defer s.updateOrderMutex.Unlock()
+
+	err := s.orderUsc.RewindSyncCursor(r.Context(), r.PathValue("provider"), reqObj.LastTs, reqObj.LastOrderID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ResetSyncCursorHandler drops the sync cursor of a provider, forcing a full import on the next run
+func (s *Rest) ResetSyncCursorHandler(w http.ResponseWriter, r *http.Request) {
+	s.updateOrderMutex.Lock()
+	defer s.updateOrderMutex.Unlock()
+
+	if err := s.orderUsc.ResetSyncCursor(r.Context(), r.PathValue("provider")); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HealthHandler reports the service health and the state of outbound circuit breakers.
+// The service is degraded while any breaker is not closed.
+func (s *Rest) HealthHandler(w http.ResponseWriter, r *http.Request) {
+	result := &HealthRepSt{
+		Status:   "ok",
+		Breakers: make([]*BreakerStateSt, 0, len(s.breakers)),
+	}
+
+	for _, b := range s.breakers {
+		snapshot := b.Snapshot()
+
+		state := &BreakerStateSt{
+			Name:     snapshot.Name,
+			State:    string(snapshot.State),
+			Failures: snapshot.Failures,
+		}
+		if snapshot.State != breaker.StateClosed {
+			result.Status = "degraded"
+			state.OpenedAt = &snapshot.OpenedAt
+		}
+
+		result.Breakers = append(result.Breakers, state)
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
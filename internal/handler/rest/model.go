@@ -0,0 +1,141 @@
+package rest
+
+import "time"
+
+type SyncCursorRepSt struct {
+	Provider    string    `json:"provider"`
+	LastTs      time.Time `json:"last_ts"`
+	LastOrderID string    `json:"last_order_id"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type SyncCursorRewindReqSt struct {
+	LastTs      time.Time `json:"last_ts"`
+	LastOrderID string    `json:"last_order_id"`
+}
+
+type ErrorRepSt struct {
+	Error string `json:"error"`
+}
+
+type HealthRepSt struct {
+	Status   string            `json:"status"`
+	Breakers []*BreakerStateSt `json:"breakers"`
+}
+
+type BreakerStateSt struct {
+	Name     string     `json:"name"`
+	State    string     `json:"state"`
+	Failures int        `json:"failures"`
+	OpenedAt *time.Time `json:"opened_at,omitempty"`
+}
+
+type NotificationRepSt struct {
+	ID                string     `json:"id"`
+	OrderItemID       string     `json:"order_item_id"`
+	PhoneNumber       string     `json:"phone_number"`
+	Status            string     `json:"status"`
+	Reason            string     `json:"reason,omitempty"`
+	ProviderMessageID string     `json:"provider_message_id,omitempty"`
+	ResponseCode      int        `json:"response_code,omitempty"`
+	SentAt            *time.Time `json:"sent_at,omitempty"`
+	DeliveredAt       *time.Time `json:"delivered_at,omitempty"`
+	ReadAt            *time.Time `json:"read_at,omitempty"`
+	UndeliveredAt     *time.Time `json:"undelivered_at,omitempty"`
+	AttemptCount      int        `json:"attempt_count"`
+	NextAttemptAt     *time.Time `json:"next_attempt_at,omitempty"`
+	LastError         string     `json:"last_error,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+type DeliveryCallbackRepSt struct {
+	Received int `json:"received"`
+	Applied  int `json:"applied"`
+}
+
+type MessageTemplateRepSt struct {
+	ID         string            `json:"id"`
+	Locale     string            `json:"locale"`
+	Provider   string            `json:"provider"`
+	Category   string            `json:"category"`
+	TemplateID string            `json:"template_id"`
+	Params     map[string]string `json:"params"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+type MessageTemplateReqSt struct {
+	Locale     string            `json:"locale"`
+	Provider   string            `json:"provider"`
+	Category   string            `json:"category"`
+	TemplateID string            `json:"template_id"`
+	Params     map[string]string `json:"params"`
+}
+
+type FeedbackDelayRepSt struct {
+	Category     string    `json:"category"`
+	Delay        string    `json:"delay"`
+	DelaySeconds int64     `json:"delay_seconds"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// FeedbackDelayReqSt sets the delay of a category, an empty category sets the default delay.
+// Delay is a Go duration such as "36h" or a number of days such as "3d".
+type FeedbackDelayReqSt struct {
+	Category string `json:"category"`
+	Delay    string `json:"delay"`
+}
+
+type ProductRepSt struct {
+	ProductCode string    `json:"product_code"`
+	ProductName string    `json:"product_name"`
+	UnitPrice   float64   `json:"unit_price"`
+	Category    string    `json:"category"`
+	MerchantSKU string    `json:"merchant_sku"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ProductReqSt is a product catalog entry. The catalog completes order items
+// that come with a product code only, as mb-broker returns them.
+type ProductReqSt struct {
+	ProductCode string  `json:"product_code"`
+	ProductName string  `json:"product_name"`
+	UnitPrice   float64 `json:"unit_price"`
+	Category    string  `json:"category"`
+	MerchantSKU string  `json:"merchant_sku"`
+}
+
+type SuppressionRepSt struct {
+	Phone     string    `json:"phone"`
+	Reason    string    `json:"reason,omitempty"`
+	Source    string    `json:"source"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type SuppressionReqSt struct {
+	Phone  string `json:"phone"`
+	Reason string `json:"reason"`
+	Source string `json:"source"`
+}
+
+type CustomerRepSt struct {
+	Phone       string    `json:"phone"`
+	FirstName   string    `json:"first_name"`
+	LastName    string    `json:"last_name"`
+	Email       string    `json:"email,omitempty"`
+	Locale      string    `json:"locale,omitempty"`
+	Timezone    string    `json:"timezone,omitempty"`
+	FirstSeenAt time.Time `json:"first_seen_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+	OrderCount  int       `json:"order_count"`
+}
+
+// CustomerReqSt changes customer preferences. Omitted fields are kept,
+// empty ones fall back to the configured defaults.
+type CustomerReqSt struct {
+	Locale   *string `json:"locale"`
+	Timezone *string `json:"timezone"`
+}
+
+type IDRepSt struct {
+	ID string `json:"id"`
+}
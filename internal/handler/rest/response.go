@@ -0,0 +1,47 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"mb-feedback/internal/errs"
+	"net/http"
+)
+
+// writeJSON encodes obj as the JSON response body with the given status code.
+func writeJSON(w http.ResponseWriter, statusCode int, obj any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	if obj == nil {
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(obj); err != nil {
+		slog.Error("Error encoding response", "error", err)
+	}
+}
+
+// writeError maps err to an HTTP status code and writes it as ErrorRepSt.
+func writeError(w http.ResponseWriter, err error) {
+	statusCode := http.StatusInternalServerError
+
+	switch {
+	case errors.Is(err, errs.TokenUnavailable):
+		statusCode = http.StatusServiceUnavailable
+	case errors.Is(err, errs.InvalidInput):
+		statusCode = http.StatusBadRequest
+	case errors.Is(err, errs.ObjectNotFound):
+		statusCode = http.StatusNotFound
+	case errors.Is(err, errs.Unauthorized):
+		statusCode = http.StatusUnauthorized
+	case errors.Is(err, errs.CircuitOpen):
+		statusCode = http.StatusServiceUnavailable
+	}
+
+	if statusCode == http.StatusInternalServerError {
+		slog.Error("Request failed", "error", err)
+	}
+
+	writeJSON(w, statusCode, &ErrorRepSt{Error: err.Error()})
+}
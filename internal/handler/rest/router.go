@@ -4,9 +4,13 @@ import (
 	"context"
 	"errors"
 	"log/slog"
+	"mb-feedback/internal/client/breaker"
+	customerUsecase "mb-feedback/internal/usecase/customer"
 	notificationUsecase "mb-feedback/internal/usecase/notification"
 	orderUsecase "mb-feedback/internal/usecase/order"
 	orderDetailUsecase "mb-feedback/internal/usecase/order_detail"
+	orderImportUsecase "mb-feedback/internal/usecase/order_import"
+	suppressionUsecase "mb-feedback/internal/usecase/suppression"
 	"net/http"
 	"sync"
 	"time"
@@ -17,6 +21,14 @@ type Rest struct {
 	orderUsc        *orderUsecase.Usecase
 	orderDetailUsc  *orderDetailUsecase.Usecase
 	notificationUsc *notificationUsecase.Usecase
+	orderImportUsc  *orderImportUsecase.Usecase
+	suppressionUsc  *suppressionUsecase.Usecase
+	customerUsc     *customerUsecase.Usecase
+	breakers        []*breaker.Breaker
+
+	webhookVerifier *signatureVerifier
+	callbackToken   string
+	importProvider  string
 
 	updateOrderMutex      sync.Mutex
 	getProductCodeMutex   sync.Mutex
@@ -25,11 +37,31 @@ type Rest struct {
 	ErrorChan chan error
 }
 
-func New(orderUsc *orderUsecase.Usecase, orderDetailUsc *orderDetailUsecase.Usecase, notificationUsc *notificationUsecase.Usecase) *Rest {
+func New(
+	orderUsc *orderUsecase.Usecase,
+	orderDetailUsc *orderDetailUsecase.Usecase,
+	notificationUsc *notificationUsecase.Usecase,
+	orderImportUsc *orderImportUsecase.Usecase,
+	suppressionUsc *suppressionUsecase.Usecase,
+	customerUsc *customerUsecase.Usecase,
+	breakers []*breaker.Breaker,
+	webhookSecret string,
+	webhookTolerance time.Duration,
+	callbackToken string,
+	webhookNonceSrv NonceServiceI,
+	importProvider string) *Rest {
 	return &Rest{
 		orderUsc:        orderUsc,
 		orderDetailUsc:  orderDetailUsc,
 		notificationUsc: notificationUsc,
+		orderImportUsc:  orderImportUsc,
+		suppressionUsc:  suppressionUsc,
+		customerUsc:     customerUsc,
+		breakers:        breakers,
+
+		webhookVerifier: newSignatureVerifier(webhookSecret, webhookTolerance, webhookNonceSrv),
+		callbackToken:   callbackToken,
+		importProvider:  importProvider,
 
 		ErrorChan: make(chan error, 1),
 	}
@@ -38,10 +70,41 @@ func New(orderUsc *orderUsecase.Usecase, orderDetailUsc *orderDetailUsecase.Usec
 func (s *Rest) Start(addr string) {
 
 	httpMux := http.NewServeMux()
+	httpMux.HandleFunc("GET /health", s.HealthHandler)
+
 	httpMux.HandleFunc("GET /fetch-orders", s.FetchOrdersHandler)
 	httpMux.HandleFunc("GET /get-product-codes", s.GetProductCodesHandler)
 	httpMux.HandleFunc("GET /send-notification", s.SendNotificationHandler)
 
+	httpMux.HandleFunc("POST /webhooks/orders", s.OrderWebhookHandler)
+	httpMux.HandleFunc("POST /orders/import", s.ImportOrdersHandler)
+	httpMux.HandleFunc("POST /callbacks/voximplant", s.VoximplantCallbackHandler)
+
+	httpMux.HandleFunc("GET /notifications", s.ListNotificationsHandler)
+
+	httpMux.HandleFunc("GET /message-templates", s.ListMessageTemplatesHandler)
+	httpMux.HandleFunc("PUT /message-templates", s.SetMessageTemplateHandler)
+	httpMux.HandleFunc("DELETE /message-templates/{id}", s.DeleteMessageTemplateHandler)
+
+	httpMux.HandleFunc("GET /feedback-delays", s.ListFeedbackDelaysHandler)
+	httpMux.HandleFunc("PUT /feedback-delays", s.SetFeedbackDelayHandler)
+	httpMux.HandleFunc("DELETE /feedback-delays", s.DeleteFeedbackDelayHandler)
+	httpMux.HandleFunc("GET /products", s.ListProductsHandler)
+	httpMux.HandleFunc("PUT /products", s.SetProductsHandler)
+	httpMux.HandleFunc("DELETE /products/{code}", s.DeleteProductHandler)
+
+	httpMux.HandleFunc("POST /suppressions", s.AddSuppressionHandler)
+	httpMux.HandleFunc("POST /suppressions/import", s.ImportSuppressionsHandler)
+	httpMux.HandleFunc("GET /suppressions/{phone}", s.GetSuppressionHandler)
+	httpMux.HandleFunc("DELETE /suppressions/{phone}", s.DeleteSuppressionHandler)
+
+	httpMux.HandleFunc("GET /customers/{phone}", s.GetCustomerHandler)
+	httpMux.HandleFunc("PATCH /customers/{phone}", s.UpdateCustomerHandler)
+
+	httpMux.HandleFunc("GET /sync-cursors", s.ListSyncCursorsHandler)
+	httpMux.HandleFunc("PUT /sync-cursors/{provider}", s.RewindSyncCursorHandler)
+	httpMux.HandleFunc("DELETE /sync-cursors/{provider}", s.ResetSyncCursorHandler)
+
 	s.httpServer = &http.Server{
 		Addr:    addr,
 		Handler: httpMux,
@@ -64,7 +127,7 @@ func (s *Rest) Stop() error {
 	defer cancel()
 
 	if err := s.httpServer.Shutdown(ctx); err != nil {
-		slog.Error("Server forced to shutdown:", err)
+		slog.Error("Server forced to shutdown:", "error", err)
 		return err
 	}
 
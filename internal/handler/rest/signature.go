@@ -0,0 +1,116 @@
+package rest
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"mb-feedback/internal/errs"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	headerCallbackToken = "X-Callback-Token"
+
+	headerSignature = "X-Signature"
+	headerTimestamp = "X-Timestamp"
+	headerNonce     = "X-Nonce"
+
+	// maxNonceLen is the size of the webhook_nonce column.
+	maxNonceLen = 255
+)
+
+type NonceServiceI interface {
+	Use(ctx context.Context, nonce string, expiresAt time.Time) (bool, error)
+}
+
+// signatureVerifier authenticates pushed requests signed with a shared secret.
+// The signature is the hex HMAC-SHA256 of "<timestamp>.<nonce>.<body>".
+// Requests outside the timestamp tolerance or reusing a seen nonce are rejected as replays.
+// Seen nonces are kept in the database, so a replay to another instance or after
+// a restart is rejected too.
+type signatureVerifier struct {
+	secret    []byte
+	tolerance time.Duration
+	nonces    NonceServiceI
+}
+
+func newSignatureVerifier(secret string, tolerance time.Duration, nonces NonceServiceI) *signatureVerifier {
+	return &signatureVerifier{
+		secret:    []byte(secret),
+		tolerance: tolerance,
+		nonces:    nonces,
+	}
+}
+
+func (v *signatureVerifier) verify(r *http.Request, body []byte) error {
+	if len(v.secret) == 0 {
+		return fmt.Errorf("%w: signing secret is not configured", errs.Unauthorized)
+	}
+
+	signature := r.Header.Get(headerSignature)
+	timestamp := r.Header.Get(headerTimestamp)
+	nonce := r.Header.Get(headerNonce)
+	if signature == "" || timestamp == "" || nonce == "" {
+		return fmt.Errorf("%w: missing signature headers", errs.Unauthorized)
+	}
+	if len(nonce) > maxNonceLen {
+		return fmt.Errorf("%w: nonce is longer than %d characters", errs.Unauthorized, maxNonceLen)
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: bad timestamp", errs.Unauthorized)
+	}
+
+	sentAt := time.Unix(ts, 0)
+	if age := time.Since(sentAt); age > v.tolerance || age < -v.tolerance {
+		return fmt.Errorf("%w: timestamp outside tolerance", errs.Unauthorized)
+	}
+
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("%w: bad signature", errs.Unauthorized)
+	}
+
+	mac := hmac.New(sha256.New, v.secret)
+	mac.Write([]byte(timestamp + "." + nonce + "."))
+	mac.Write(body)
+	if !hmac.Equal(mac.Sum(nil), expected) {
+		return fmt.Errorf("%w: signature mismatch", errs.Unauthorized)
+	}
+
+	// the nonce is checked last, so unsigned requests cannot burn nonces
+	used, err := v.nonces.Use(r.Context(), nonce, sentAt.Add(v.tolerance))
+	if err != nil {
+		return fmt.Errorf("failed to check nonce: %w", err)
+	}
+	if !used {
+		return fmt.Errorf("%w: replayed nonce", errs.Unauthorized)
+	}
+
+	return nil
+}
+
+// verifyToken authenticates callbacks of providers that cannot sign requests,
+// e.g. Voximplant, by a shared token sent in a header or the query.
+func verifyToken(r *http.Request, token string) error {
+	if token == "" {
+		return fmt.Errorf("%w: callback token is not configured", errs.Unauthorized)
+	}
+
+	got := r.Header.Get(headerCallbackToken)
+	if got == "" {
+		got = r.URL.Query().Get("token")
+	}
+
+	if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+		return fmt.Errorf("%w: bad callback token", errs.Unauthorized)
+	}
+
+	return nil
+}
@@ -0,0 +1,198 @@
+package rest
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"mb-feedback/internal/errs"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testSecret = "webhook-secret"
+
+func TestSignatureVerifier(t *testing.T) {
+	body := []byte(`{"event":"order.completed"}`)
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		noSecret bool
+		ts       time.Time
+		nonce    string
+		sign     func(ts, nonce string) string
+		body     []byte
+		wantErr  error
+	}{
+		{
+			name: "valid",
+			ts:   now, nonce: "n-valid",
+		},
+		{
+			name: "timestamp within tolerance",
+			ts:   now.Add(-4 * time.Minute), nonce: "n-recent",
+		},
+		{
+			name: "signed with another secret",
+			ts:   now, nonce: "n-other-secret",
+			sign: func(ts, nonce string) string {
+				return sign("another-secret", ts, nonce, body)
+			},
+			wantErr: errs.Unauthorized,
+		},
+		{
+			name: "tampered body",
+			ts:   now, nonce: "n-tampered",
+			body:    []byte(`{"event":"order.cancelled"}`),
+			wantErr: errs.Unauthorized,
+		},
+		{
+			name: "signature is not hex",
+			ts:   now, nonce: "n-not-hex",
+			sign:    func(string, string) string { return "not-hex" },
+			wantErr: errs.Unauthorized,
+		},
+		{
+			name: "missing signature",
+			ts:   now, nonce: "n-missing",
+			sign:    func(string, string) string { return "" },
+			wantErr: errs.Unauthorized,
+		},
+		{
+			name: "stale timestamp",
+			ts:   now.Add(-6 * time.Minute), nonce: "n-stale",
+			wantErr: errs.Unauthorized,
+		},
+		{
+			name: "timestamp in the future",
+			ts:   now.Add(6 * time.Minute), nonce: "n-future",
+			wantErr: errs.Unauthorized,
+		},
+		{
+			name: "nonce too long",
+			ts:   now, nonce: strings.Repeat("n", maxNonceLen+1),
+			wantErr: errs.Unauthorized,
+		},
+		{
+			name:     "secret not configured",
+			noSecret: true,
+			ts:       now, nonce: "n-no-secret",
+			wantErr: errs.Unauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret := testSecret
+			if tt.noSecret {
+				secret = ""
+			}
+			nonces := &fakeNonceService{}
+			v := newSignatureVerifier(secret, 5*time.Minute, nonces)
+
+			reqBody := body
+			if tt.body != nil {
+				reqBody = tt.body
+			}
+
+			err := v.verify(signedRequest(tt.ts, tt.nonce, body, tt.sign), reqBody)
+			if tt.wantErr == nil && err != nil {
+				t.Fatalf("verify: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Fatalf("verify = %v, want %v", err, tt.wantErr)
+			}
+
+			// only requests with a valid signature and timestamp may use up a nonce
+			if used := len(nonces.used) > 0; used != (tt.wantErr == nil) {
+				t.Errorf("nonce used = %v, want %v", used, tt.wantErr == nil)
+			}
+		})
+	}
+}
+
+func TestSignatureVerifierRejectsReplayedNonce(t *testing.T) {
+	body := []byte(`{"event":"order.completed"}`)
+	nonces := &fakeNonceService{}
+
+	// two verifiers sharing the nonce store stand for two instances
+	first := newSignatureVerifier(testSecret, 5*time.Minute, nonces)
+	second := newSignatureVerifier(testSecret, 5*time.Minute, nonces)
+
+	ts := time.Now()
+	if err := first.verify(signedRequest(ts, "n-once", body, nil), body); err != nil {
+		t.Fatalf("first verify: %v", err)
+	}
+
+	for name, v := range map[string]*signatureVerifier{"same instance": first, "another instance": second} {
+		if err := v.verify(signedRequest(ts, "n-once", body, nil), body); !errors.Is(err, errs.Unauthorized) {
+			t.Errorf("%s: replay verify = %v, want %v", name, err, errs.Unauthorized)
+		}
+	}
+
+	if got := nonces.used["n-once"]; !got.Equal(time.Unix(ts.Unix(), 0).Add(5 * time.Minute)) {
+		t.Errorf("nonce expires at %s, want the timestamp plus the tolerance", got)
+	}
+}
+
+func TestSignatureVerifierNonceStoreError(t *testing.T) {
+	body := []byte(`{}`)
+	v := newSignatureVerifier(testSecret, 5*time.Minute, &fakeNonceService{err: errors.New("connection refused")})
+
+	err := v.verify(signedRequest(time.Now(), "n-store-down", body, nil), body)
+	if err == nil || errors.Is(err, errs.Unauthorized) {
+		t.Errorf("verify = %v, want a non-auth error", err)
+	}
+}
+
+func signedRequest(ts time.Time, nonce string, body []byte, signFunc func(ts, nonce string) string) *http.Request {
+	timestamp := strconv.FormatInt(ts.Unix(), 10)
+
+	signature := sign(testSecret, timestamp, nonce, body)
+	if signFunc != nil {
+		signature = signFunc(timestamp, nonce)
+	}
+
+	r, _ := http.NewRequest(http.MethodPost, "/webhooks/orders", nil)
+	r.Header.Set(headerTimestamp, timestamp)
+	r.Header.Set(headerNonce, nonce)
+	if signature != "" {
+		r.Header.Set(headerSignature, signature)
+	}
+
+	return r
+}
+
+func sign(secret, timestamp, nonce string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + nonce + "."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// fakeNonceService keeps used nonces in memory like the webhook_nonce table does.
+type fakeNonceService struct {
+	used map[string]time.Time
+	err  error
+}
+
+func (s *fakeNonceService) Use(_ context.Context, nonce string, expiresAt time.Time) (bool, error) {
+	if s.err != nil {
+		return false, s.err
+	}
+	if s.used == nil {
+		s.used = make(map[string]time.Time)
+	}
+	if _, ok := s.used[nonce]; ok {
+		return false, nil
+	}
+	s.used[nonce] = expiresAt
+
+	return true, nil
+}
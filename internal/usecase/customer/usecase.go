@@ -0,0 +1,95 @@
+package customer
+
+import (
+	"context"
+	"fmt"
+	customerModel "mb-feedback/internal/domain/customer/model"
+	"mb-feedback/internal/errs"
+	"strings"
+	"time"
+)
+
+// maxLocaleLen is the size of the customer locale column.
+const maxLocaleLen = 5
+
+type OrderServiceI interface {
+	FormatPhoneNumber(phone string) (string, error)
+}
+
+type CustomerServiceI interface {
+	Get(ctx context.Context, pars *customerModel.GetPars, errNE bool) (*customerModel.Customer, bool, error)
+	Update(ctx context.Context, pars *customerModel.GetPars, obj *customerModel.Edit) error
+}
+
+type Usecase struct {
+	orderService    OrderServiceI
+	customerService CustomerServiceI
+}
+
+func New(orderService OrderServiceI, customerService CustomerServiceI) *Usecase {
+	return &Usecase{
+		orderService:    orderService,
+		customerService: customerService,
+	}
+}
+
+// Get returns the customer with the phone number or errs.ObjectNotFound.
+func (u *Usecase) Get(ctx context.Context, phone string) (*customerModel.Customer, error) {
+	normalized, err := u.normalizePhone(phone)
+	if err != nil {
+		return nil, err
+	}
+
+	result, _, err := u.customerService.Get(ctx, &customerModel.GetPars{Phone: normalized}, true)
+	return result, err
+}
+
+// SetPreferences changes the language messages are sent in and the timezone the send window
+// is applied in. A nil value is left as is, an empty one falls back to the defaults.
+func (u *Usecase) SetPreferences(ctx context.Context, phone string, locale, timezone *string) (*customerModel.Customer, error) {
+	normalized, err := u.normalizePhone(phone)
+	if err != nil {
+		return nil, err
+	}
+
+	if locale == nil && timezone == nil {
+		return nil, fmt.Errorf("%w: locale or timezone is required", errs.InvalidInput)
+	}
+
+	obj := &customerModel.Edit{Phone: normalized}
+
+	if locale != nil {
+		value := strings.ToLower(strings.TrimSpace(*locale))
+		if len(value) > maxLocaleLen {
+			return nil, fmt.Errorf("%w: locale is longer than %d characters", errs.InvalidInput, maxLocaleLen)
+		}
+		obj.Locale = &value
+	}
+
+	if timezone != nil {
+		value := strings.TrimSpace(*timezone)
+		if value != "" {
+			if _, err = time.LoadLocation(value); err != nil || value == "Local" {
+				return nil, fmt.Errorf("%w: unknown timezone %s", errs.InvalidInput, value)
+			}
+		}
+		obj.Timezone = &value
+	}
+
+	if err = u.customerService.Update(ctx, &customerModel.GetPars{Phone: normalized}, obj); err != nil {
+		return nil, err
+	}
+
+	result, _, err := u.customerService.Get(ctx, &customerModel.GetPars{Phone: normalized}, true)
+	return result, err
+}
+
+// normalizePhone brings the phone number to the format customers are stored with.
+func (u *Usecase) normalizePhone(phone string) (string, error) {
+	result, err := u.orderService.FormatPhoneNumber(strings.TrimPrefix(strings.TrimSpace(phone), "+"))
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", errs.InvalidInput, err.Error())
+	}
+
+	return result, nil
+}
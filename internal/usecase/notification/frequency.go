@@ -0,0 +1,78 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"mb-feedback/internal/cns"
+	notificationModel "mb-feedback/internal/domain/notification/model"
+	orderDetail "mb-feedback/internal/domain/order_detail/model"
+	"time"
+)
+
+// FrequencyCap limits the number of messages sent to one phone number within Period.
+type FrequencyCap struct {
+	Period time.Duration
+	Limit  int
+}
+
+func (c FrequencyCap) String() string {
+	period := c.Period.String()
+	if c.Period%(24*time.Hour) == 0 {
+		period = fmt.Sprintf("%dd", c.Period/(24*time.Hour))
+	}
+
+	return fmt.Sprintf("%d per %s", c.Limit, period)
+}
+
+// sentStatuses are the statuses of notifications that reached the provider,
+// only these count towards the frequency caps.
+var sentStatuses = []string{cns.StatusSent, cns.StatusDelivered, cns.StatusRead, cns.StatusUndelivered}
+
+// frequencyCapReached returns the first cap the phone number has reached, if any.
+func (u *Usecase) frequencyCapReached(ctx context.Context, phone string) (*FrequencyCap, error) {
+	now := time.Now()
+
+	for i := range u.caps {
+		c := &u.caps[i]
+
+		sentAfter := now.Add(-c.Period)
+		sent, err := u.notificationService.Count(ctx, &notificationModel.ListPars{
+			PhoneNumber: &phone,
+			Statuses:    &sentStatuses,
+			SentAfter:   &sentAfter,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to count notifications sent to the customer: %w", err)
+		}
+
+		if sent >= int64(c.Limit) {
+			return c, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// sendWithinCaps sends the notification as the given attempt unless the customer
+// has reached a frequency cap, in which case it is SUPPRESSED.
+// The caller holds the phone lock until the outcome is stored, so that concurrent
+// runs do not both pass the check.
+func (u *Usecase) sendWithinCaps(ctx context.Context, detail *orderDetail.OrderDetailWithUserInfo, attempt int) (*notificationModel.Edit, error) {
+	c, err := u.frequencyCapReached(ctx, detail.UserPhone)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return u.send(ctx, detail, attempt)
+	}
+
+	status, reason := cns.StatusSuppressed, "frequency cap "+c.String()
+
+	slog.Info("Notification suppressed", "detailID", detail.ID, "reason", reason)
+
+	return &notificationModel.Edit{
+		Status: &status,
+		Reason: &reason,
+	}, nil
+}
@@ -0,0 +1,137 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"mb-feedback/internal/cns"
+	notificationModel "mb-feedback/internal/domain/notification/model"
+	orderDetail "mb-feedback/internal/domain/order_detail/model"
+	"time"
+)
+
+// RetryPolicy configures how FAILED notifications are re-sent.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of send attempts, including the first one.
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry, doubled on every next one.
+	BaseDelay time.Duration
+	// MaxDelay caps the exponential backoff delay.
+	MaxDelay time.Duration
+}
+
+// delay returns how long to wait before the retry following the given attempt.
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	backoff := p.BaseDelay << (attempt - 1)
+	if backoff <= 0 || (p.MaxDelay > 0 && backoff > p.MaxDelay) {
+		backoff = p.MaxDelay
+	}
+
+	return backoff
+}
+
+// retryFailed sends FAILED and DEFERRED notifications whose next attempt is due.
+// Notifications of orders returned or cancelled in the meantime are skipped instead,
+// ones of customers who opted out are marked OPTED_OUT,
+// ones outside the customer send window wait for it to open and ones over
+// the customer frequency cap are suppressed.
+func (u *Usecase) retryFailed(ctx context.Context) error {
+	now := time.Now()
+	statuses := []string{cns.StatusFailed, cns.StatusDeferred}
+
+	notifications, _, err := u.notificationService.List(ctx, &notificationModel.ListPars{
+		Statuses:          &statuses,
+		NextAttemptBefore: &now,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list failed notifications: %w", err)
+	}
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	detailIDs := make([]string, 0, len(notifications))
+	for _, n := range notifications {
+		detailIDs = append(detailIDs, n.OrderItemID)
+	}
+
+	details, err := u.orderDetailService.ListDetailWithUserInfo(ctx, &orderDetail.ListPars{IDs: &detailIDs})
+	if err != nil {
+		return fmt.Errorf("failed to list details of failed notifications: %w", err)
+	}
+
+	detailsByID := make(map[string]*orderDetail.OrderDetailWithUserInfo, len(details))
+	for _, detail := range details {
+		detailsByID[detail.ID] = detail
+	}
+
+	retried := 0
+	for _, n := range notifications {
+		detail, ok := detailsByID[n.OrderItemID]
+		if !ok {
+			slog.Warn("Failed notification without order detail", "notificationID", n.ID, "detailID", n.OrderItemID)
+			continue
+		}
+
+		if err = u.retryNotification(ctx, n, detail); err != nil {
+			return err
+		}
+
+		retried++
+	}
+
+	slog.Info("Retried due notifications", "due", len(notifications), "retried", retried)
+
+	return nil
+}
+
+// retryNotification sends the due notification again and stores the outcome,
+// unless an overlapping run has done it in the meantime.
+func (u *Usecase) retryNotification(ctx context.Context, n *notificationModel.Notification, detail *orderDetail.OrderDetailWithUserInfo) error {
+	unlock, err := u.notificationService.LockPhone(ctx, detail.UserPhone)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// an overlapping run may have retried the notification since it was listed
+	now := time.Now()
+	current, _, err := u.notificationService.List(ctx, &notificationModel.ListPars{
+		ID:                &n.ID,
+		Status:            &n.Status,
+		NextAttemptBefore: &now,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get notification %s: %w", n.ID, err)
+	}
+	if len(current) == 0 {
+		return nil
+	}
+
+	suppression, err := u.suppression(ctx, detail.UserPhone)
+	if err != nil {
+		return err
+	}
+
+	var obj *notificationModel.Edit
+	if detail.OrderStatus == cns.OrderStatusReturned || detail.OrderStatus == cns.OrderStatusCancelled {
+		skipped, reason := cns.StatusSkipped, "order "+detail.OrderStatus
+		obj = &notificationModel.Edit{Status: &skipped, Reason: &reason}
+	} else if suppression != nil {
+		obj = optOutNotification(detail, suppression)
+	} else if opening, ok := u.sendWindowOpening(detail); !ok {
+		// a failed notification stays FAILED, only its next attempt moves
+		obj = &notificationModel.Edit{NextAttemptAt: &opening}
+	} else {
+		if obj, err = u.sendWithinCaps(ctx, detail, n.AttemptCount+1); err != nil {
+			return fmt.Errorf("failed to retry notification %s: %w", n.ID, err)
+		}
+	}
+
+	// matching the status keeps changes made by a concurrent run from being overwritten
+	if err = u.notificationService.Update(ctx, &notificationModel.GetPars{ID: n.ID, Status: n.Status}, obj); err != nil {
+		return fmt.Errorf("failed to update notification %s: %w", n.ID, err)
+	}
+
+	return nil
+}
@@ -2,39 +2,123 @@ package notification
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"mb-feedback/internal/client/notifier"
 	"mb-feedback/internal/cns"
+	messageTemplateModel "mb-feedback/internal/domain/message_template/model"
 	notificationModel "mb-feedback/internal/domain/notification/model"
 	orderDetail "mb-feedback/internal/domain/order_detail/model"
+	suppressionModel "mb-feedback/internal/domain/suppression/model"
+	"mb-feedback/internal/errs"
+	"slices"
+	"strings"
 	"time"
 )
 
 type OrderDetailServiceI interface {
 	ListDetailWithoutNotification(ctx context.Context, pars *orderDetail.ListPars) ([]*orderDetail.OrderDetailWithUserInfo, error)
+	ListDetailWithUserInfo(ctx context.Context, pars *orderDetail.ListPars) ([]*orderDetail.OrderDetailWithUserInfo, error)
 }
 
 type NotificationServiceI interface {
-	Notify(ctx context.Context, orderID, userPhone, userName, productCode string) error
+	Notify(ctx context.Context, msg *notifier.Message) (*notifier.Result, error)
 	Create(ctx context.Context, obj *notificationModel.Edit) error
+	Update(ctx context.Context, pars *notificationModel.GetPars, obj *notificationModel.Edit) error
+	List(ctx context.Context, pars *notificationModel.ListPars) ([]*notificationModel.Notification, int64, error)
+	Count(ctx context.Context, pars *notificationModel.ListPars) (int64, error)
+	ApplyDeliveryStatus(ctx context.Context, providerMessageID, status string, ts time.Time) (bool, error)
+	LockPhone(ctx context.Context, phone string) (func(), error)
+}
+
+// DeliveryEvent is a delivery status change of a sent message reported by the provider.
+type DeliveryEvent struct {
+	ProviderMessageID string
+	Status            string
+	Ts                time.Time
+}
+
+type MessageTemplateServiceI interface {
+	Resolve(ctx context.Context, locale, provider, category string) (*messageTemplateModel.Resolved, error)
+	List(ctx context.Context, pars *messageTemplateModel.ListPars) ([]*messageTemplateModel.MessageTemplate, int64, error)
+	Set(ctx context.Context, obj *messageTemplateModel.Edit) (string, error)
+	Delete(ctx context.Context, pars *messageTemplateModel.GetPars) error
+}
+
+type SuppressionServiceI interface {
+	Get(ctx context.Context, pars *suppressionModel.GetPars, errNE bool) (*suppressionModel.Suppression, bool, error)
 }
 
 type Usecase struct {
-	orderDetailService  OrderDetailServiceI
-	notificationService NotificationServiceI
+	orderDetailService     OrderDetailServiceI
+	notificationService    NotificationServiceI
+	messageTemplateService MessageTemplateServiceI
+	suppressionService     SuppressionServiceI
+
+	defaultLocale string
+	retry         RetryPolicy
+	window        *SendWindow
+	lookback      time.Duration
+	caps          []FrequencyCap
+	// ignoredStatuses are statuses of notifications after which a detail is notified again
+	ignoredStatuses *[]string
 }
 
-func New(orderDetailService OrderDetailServiceI, notificationService NotificationServiceI) *Usecase {
-	return &Usecase{
-		orderDetailService:  orderDetailService,
-		notificationService: notificationService,
+// New creates the usecase. defaultLocale is used to pick the message template
+// for customers whose language is unknown. Details whose feedback was scheduled
+// more than lookback ago are not notified anymore and are recorded as skipped.
+// caps limit how many messages one customer receives, whatever the number of
+// ordered items. Customers on the suppression list get no messages at all.
+// With resendSandboxed details whose messages were only stored by the sandbox
+// notifier are notified again. It must only be set when the notifier sends for real.
+func New(
+	orderDetailService OrderDetailServiceI,
+	notificationService NotificationServiceI,
+	messageTemplateService MessageTemplateServiceI,
+	defaultLocale string,
+	retry RetryPolicy,
+	window *SendWindow,
+	lookback time.Duration,
+	caps []FrequencyCap,
+	suppressionService SuppressionServiceI,
+	resendSandboxed bool) *Usecase {
+	result := &Usecase{
+		orderDetailService:     orderDetailService,
+		notificationService:    notificationService,
+		messageTemplateService: messageTemplateService,
+		suppressionService:     suppressionService,
+		defaultLocale:          defaultLocale,
+		retry:                  retry,
+		window:                 window,
+		lookback:               lookback,
+		caps:                   caps,
+	}
+	if resendSandboxed {
+		result.ignoredStatuses = &[]string{cns.StatusSandboxed}
 	}
+
+	return result
 }
 
+// SendNotification retries failed notifications that are due and then notifies
+// about order details whose scheduled feedback time has come.
 func (u *Usecase) SendNotification(ctx context.Context) error {
-	createdAfter := time.Now().Add(-time.Hour) // заказы за крайний час
+	if err := u.retryFailed(ctx); err != nil {
+		return fmt.Errorf("failed to retry notifications: %w", err)
+	}
+
+	now := time.Now()
+	scheduledAfter := now.Add(-u.lookback)
+
+	if err := u.skipExpired(ctx, scheduledAfter); err != nil {
+		return err
+	}
 
 	details, err := u.orderDetailService.ListDetailWithoutNotification(ctx, &orderDetail.ListPars{
-		CreatedAfter: &createdAfter,
+		ScheduledBefore:             &now,
+		ScheduledAfter:              &scheduledAfter,
+		IgnoredNotificationStatuses: u.ignoredStatuses,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to list details without notification: %w", err)
@@ -42,33 +126,319 @@ func (u *Usecase) SendNotification(ctx context.Context) error {
 
 	for _, detail := range details {
 		if err = u.processNotification(ctx, detail); err != nil {
-			return fmt.Errorf("failed to process notification for detail ID %d: %w", detail.ID, err)
+			return fmt.Errorf("failed to process notification for detail ID %s: %w", detail.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// skipExpired records details whose feedback was scheduled before the lookback
+// as skipped, so that they do not stay without a notification forever.
+func (u *Usecase) skipExpired(ctx context.Context, scheduledBefore time.Time) error {
+	details, err := u.orderDetailService.ListDetailWithoutNotification(ctx, &orderDetail.ListPars{
+		ScheduledBefore:             &scheduledBefore,
+		IgnoredNotificationStatuses: u.ignoredStatuses,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list expired details without notification: %w", err)
+	}
+
+	reason := fmt.Sprintf("scheduled more than %s ago", u.lookback)
+	for _, detail := range details {
+		if err = u.skipNotification(ctx, detail, reason); err != nil {
+			return fmt.Errorf("failed to skip notification for detail ID %s: %w", detail.ID, err)
 		}
 	}
 
+	if len(details) > 0 {
+		slog.Warn("Notifications skipped, feedback scheduled before lookback", "count", len(details), "lookback", u.lookback)
+	}
+
 	return nil
 }
 
 func (u *Usecase) processNotification(ctx context.Context, detail *orderDetail.OrderDetailWithUserInfo) error {
-	errNotify := u.notificationService.Notify(ctx, detail.OrderID, detail.UserPhone, detail.UserName, detail.ProductCode)
+	if detail.OrderStatus == cns.OrderStatusReturned || detail.OrderStatus == cns.OrderStatusCancelled {
+		return u.skipNotification(ctx, detail, "order "+detail.OrderStatus)
+	}
+
+	// the frequency caps only hold if nothing is sent to the customer between the check and the store
+	unlock, err := u.notificationService.LockPhone(ctx, detail.UserPhone)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// an overlapping run may have notified about the detail since it was listed
+	if notified, err := u.notified(ctx, detail.ID); err != nil || notified {
+		return err
+	}
+
+	suppression, err := u.suppression(ctx, detail.UserPhone)
+	if err != nil {
+		return err
+	}
+
+	var obj *notificationModel.Edit
+	if suppression != nil {
+		obj = optOutNotification(detail, suppression)
+	} else if opening, ok := u.sendWindowOpening(detail); !ok {
+		obj = deferNotification(opening)
+	} else if obj, err = u.sendWithinCaps(ctx, detail, 1); err != nil {
+		return err
+	}
+
+	obj.OrderItemID = &detail.ID
+	obj.PhoneNumber = &detail.UserPhone
+
+	if err = u.notificationService.Create(ctx, obj); err != nil {
+		return fmt.Errorf("failed to create notification log: %w", err)
+	}
+
+	return nil
+}
+
+// notified reports whether the detail has a notification other than the ignored ones.
+func (u *Usecase) notified(ctx context.Context, detailID string) (bool, error) {
+	notifications, _, err := u.notificationService.List(ctx, &notificationModel.ListPars{OrderItemID: &detailID})
+	if err != nil {
+		return false, fmt.Errorf("failed to list notifications of the detail: %w", err)
+	}
+
+	for _, n := range notifications {
+		if u.ignoredStatuses == nil || !slices.Contains(*u.ignoredStatuses, n.Status) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// suppression returns the suppression list entry of the phone number, nil if it may be messaged.
+func (u *Usecase) suppression(ctx context.Context, phone string) (*suppressionModel.Suppression, error) {
+	result, _, err := u.suppressionService.Get(ctx, &suppressionModel.GetPars{Phone: phone}, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check suppression list: %w", err)
+	}
+
+	return result, nil
+}
+
+// optOutNotification records that the message is not sent because the customer opted out.
+func optOutNotification(detail *orderDetail.OrderDetailWithUserInfo, suppression *suppressionModel.Suppression) *notificationModel.Edit {
+	status, reason := cns.StatusOptedOut, "opted out via "+suppression.Source
+	if suppression.Reason != "" {
+		reason += ": " + suppression.Reason
+	}
+
+	slog.Info("Notification skipped, customer opted out", "detailID", detail.ID, "reason", reason)
+
+	return &notificationModel.Edit{
+		Status: &status,
+		Reason: &reason,
+	}
+}
 
-	status := cns.StatusFailed
-	if errNotify == nil {
-		status = cns.StatusSent
+// sendWindowOpening reports whether the customer may be messaged now
+// and if not, when the send window opens in the customer timezone.
+func (u *Usecase) sendWindowOpening(detail *orderDetail.OrderDetailWithUserInfo) (time.Time, bool) {
+	if u.window == nil {
+		return time.Time{}, true
+	}
+
+	return u.window.NextOpening(time.Now(), u.window.Location(detail.UserPhone, detail.UserTimezone))
+}
+
+// deferNotification postpones sending until the send window opens. The attempt is not counted.
+func deferNotification(opening time.Time) *notificationModel.Edit {
+	status, reason, attempts := cns.StatusDeferred, "outside send window", 0
+
+	return &notificationModel.Edit{
+		Status:        &status,
+		Reason:        &reason,
+		AttemptCount:  &attempts,
+		NextAttemptAt: &opening,
+	}
+}
+
+// send sends the notification about the detail as the given attempt and returns the
+// state to store: SENT, FAILED with the next attempt time after a transient error,
+// GAVE_UP after a permanent error or the last allowed attempt, or SANDBOXED when
+// the notifier stored the message instead of sending it.
+func (u *Usecase) send(ctx context.Context, detail *orderDetail.OrderDetailWithUserInfo, attempt int) (*notificationModel.Edit, error) {
+	msg, err := u.buildMessage(ctx, detail)
+	if err != nil {
+		return nil, err
+	}
+
+	result, errNotify := u.notificationService.Notify(ctx, msg)
+	if errNotify == nil && result != nil && result.Sandboxed {
+		return sandboxedNotification(detail, result, attempt), nil
 	}
 
 	sentAt := time.Now()
 
+	obj := &notificationModel.Edit{
+		SentAt:       &sentAt,
+		AttemptCount: &attempt,
+	}
+	if result != nil {
+		obj.ResponseCode = &result.ResponseCode
+		obj.RawResponse = &result.RawResponse
+		if result.MessageID != "" {
+			obj.ProviderMessageID = &result.MessageID
+		}
+	}
+
+	status := cns.StatusSent
+	if errNotify != nil {
+		lastError := errNotify.Error()
+		obj.LastError = &lastError
+
+		var reason string
+		switch {
+		case !notifier.IsTransient(errNotify):
+			status, reason = cns.StatusGaveUp, "permanent error"
+		case attempt >= u.retry.MaxAttempts:
+			status, reason = cns.StatusGaveUp, fmt.Sprintf("no success after %d attempts", attempt)
+		default:
+			status = cns.StatusFailed
+			nextAttemptAt := sentAt.Add(u.retry.delay(attempt))
+			obj.NextAttemptAt = &nextAttemptAt
+		}
+		if reason != "" {
+			obj.Reason = &reason
+		}
+
+		slog.Warn("Failed to send notification",
+			"detailID", detail.ID, "attempt", attempt, "status", status, "nextAttemptAt", obj.NextAttemptAt, "error", errNotify)
+	}
+	obj.Status = &status
+
+	return obj, nil
+}
+
+// sandboxedNotification records that the message was stored by the sandbox notifier
+// and not sent. It has no provider message ID and does not count towards the frequency caps.
+func sandboxedNotification(detail *orderDetail.OrderDetailWithUserInfo, result *notifier.Result, attempt int) *notificationModel.Edit {
+	status := cns.StatusSandboxed
+	reason := fmt.Sprintf("%s, sandbox message %s", result.SandboxReason, result.MessageID)
+
+	slog.Info("Notification sandboxed", "detailID", detail.ID, "reason", reason)
+
+	return &notificationModel.Edit{
+		Status:       &status,
+		Reason:       &reason,
+		RawResponse:  &result.RawResponse,
+		AttemptCount: &attempt,
+	}
+}
+
+// buildMessage picks the message template for the customer locale, order provider and
+// product category and fills the template params with the order detail.
+func (u *Usecase) buildMessage(ctx context.Context, detail *orderDetail.OrderDetailWithUserInfo) (*notifier.Message, error) {
+	locale := detail.UserLocale
+	if locale == "" {
+		locale = u.defaultLocale
+	}
+
+	template, err := u.messageTemplateService.Resolve(ctx, locale, detail.OrderProvider, detail.Category)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve message template: %w", err)
+	}
+
+	msg := &notifier.Message{
+		OrderID:     detail.OrderID,
+		UserPhone:   detail.UserPhone,
+		UserName:    detail.UserName,
+		ProductCode: detail.ProductCode,
+	}
+	if template == nil {
+		return msg, nil
+	}
+
+	replacer := strings.NewReplacer(
+		"{user_name}", detail.UserName,
+		"{order_id}", detail.OrderID,
+		"{product_code}", detail.ProductCode,
+		"{product_name}", detail.ProductName,
+		"{category}", detail.Category,
+	)
+
+	msg.TemplateID = template.TemplateID
+	if len(template.Params) > 0 {
+		msg.TextParams = make(map[string]string, len(template.Params))
+		for name, value := range template.Params {
+			msg.TextParams[name] = replacer.Replace(value)
+		}
+	}
+
+	return msg, nil
+}
+
+// skipNotification records that no notification is sent for the detail and why.
+func (u *Usecase) skipNotification(ctx context.Context, detail *orderDetail.OrderDetailWithUserInfo, reason string) error {
+	status := cns.StatusSkipped
+
 	err := u.notificationService.Create(ctx, &notificationModel.Edit{
 		OrderItemID: &detail.ID,
 		PhoneNumber: &detail.UserPhone,
 		Status:      &status,
-		SentAt:      &sentAt,
+		Reason:      &reason,
 	})
-
 	if err != nil {
-		return fmt.Errorf("failed to create notification log: %w", err)
+		return fmt.Errorf("failed to create skipped notification log: %w", err)
 	}
 
 	return nil
 }
+
+// HandleDeliveryEvents applies delivery status changes to the notifications.
+// Events for unknown messages and ones that would move a status backwards are ignored.
+func (u *Usecase) HandleDeliveryEvents(ctx context.Context, events []*DeliveryEvent) (int, error) {
+	applied := 0
+
+	for _, event := range events {
+		changed, err := u.notificationService.ApplyDeliveryStatus(ctx, event.ProviderMessageID, event.Status, event.Ts)
+		if err != nil {
+			if errors.Is(err, errs.ObjectNotFound) {
+				slog.Warn("Delivery event for unknown message", "messageID", event.ProviderMessageID, "status", event.Status)
+				continue
+			}
+			return applied, fmt.Errorf("failed to apply delivery status for message %s: %w", event.ProviderMessageID, err)
+		}
+
+		if changed {
+			applied++
+		}
+	}
+
+	return applied, nil
+}
+
+// ListNotifications returns notifications with their delivery status.
+func (u *Usecase) ListNotifications(ctx context.Context, pars *notificationModel.ListPars) ([]*notificationModel.Notification, error) {
+	result, _, err := u.notificationService.List(ctx, pars)
+	return result, err
+}
+
+// ListMessageTemplates returns the configured message templates.
+func (u *Usecase) ListMessageTemplates(ctx context.Context) ([]*messageTemplateModel.MessageTemplate, error) {
+	result, _, err := u.messageTemplateService.List(ctx, &messageTemplateModel.ListPars{})
+	return result, err
+}
+
+// SetMessageTemplate creates or replaces the template for its locale, provider and category.
+func (u *Usecase) SetMessageTemplate(ctx context.Context, obj *messageTemplateModel.Edit) (string, error) {
+	if obj.TemplateID == "" {
+		return "", fmt.Errorf("%w: template_id is required", errs.InvalidInput)
+	}
+
+	return u.messageTemplateService.Set(ctx, obj)
+}
+
+// DeleteMessageTemplate removes the template, its messages fall back to less specific ones.
+func (u *Usecase) DeleteMessageTemplate(ctx context.Context, id string) error {
+	return u.messageTemplateService.Delete(ctx, &messageTemplateModel.GetPars{ID: id})
+}
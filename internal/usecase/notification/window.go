@@ -0,0 +1,104 @@
+package notification
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	_ "time/tzdata" // customer timezones must resolve in images without zoneinfo
+)
+
+// phoneTimezones maps phone number prefixes to the timezone of the region.
+// Phone numbers are normalized to +7XXXXXXXXXX, so only +7 regions are listed.
+// The longest matching prefix wins.
+var phoneTimezones = map[string]string{
+	"+77": "Asia/Almaty",   // Kazakhstan, a single timezone since 2024
+	"+73": "Europe/Moscow", // Russia, the region is not known from the number
+	"+74": "Europe/Moscow",
+	"+78": "Europe/Moscow",
+	"+79": "Europe/Moscow",
+}
+
+// SendWindow is the time of day notifications may be sent at in the customer timezone.
+// The window may wrap midnight, e.g. 22:00-06:00; an empty window allows any time.
+type SendWindow struct {
+	start, end int // minutes since midnight
+	defaultLoc *time.Location
+}
+
+// NewSendWindow parses the window bounds given as HH:MM. defaultTimezone is used
+// when the customer timezone is unknown and cannot be derived from the phone.
+func NewSendWindow(start, end, defaultTimezone string) (*SendWindow, error) {
+	result := &SendWindow{}
+
+	var err error
+	if result.start, err = parseClock(start); err != nil {
+		return nil, fmt.Errorf("bad send window start: %w", err)
+	}
+	if result.end, err = parseClock(end); err != nil {
+		return nil, fmt.Errorf("bad send window end: %w", err)
+	}
+	if result.defaultLoc, err = time.LoadLocation(defaultTimezone); err != nil {
+		return nil, fmt.Errorf("bad default timezone: %w", err)
+	}
+
+	return result, nil
+}
+
+// Location returns the customer timezone: the one stored for the customer,
+// the one of the phone region or the default one.
+func (w *SendWindow) Location(phone, timezone string) *time.Location {
+	if timezone != "" {
+		if loc, err := time.LoadLocation(timezone); err == nil {
+			return loc
+		}
+	}
+
+	prefix := ""
+	for p := range phoneTimezones {
+		if strings.HasPrefix(phone, p) && len(p) > len(prefix) {
+			prefix = p
+		}
+	}
+	if prefix != "" {
+		if loc, err := time.LoadLocation(phoneTimezones[prefix]); err == nil {
+			return loc
+		}
+	}
+
+	return w.defaultLoc
+}
+
+// NextOpening reports whether now is inside the window in loc,
+// and if it is not, when the window opens next.
+func (w *SendWindow) NextOpening(now time.Time, loc *time.Location) (time.Time, bool) {
+	if w.start == w.end {
+		return now, true
+	}
+
+	local := now.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+
+	inside := w.start <= minute && minute < w.end
+	if w.start > w.end {
+		inside = minute >= w.start || minute < w.end
+	}
+	if inside {
+		return now, true
+	}
+
+	opening := time.Date(local.Year(), local.Month(), local.Day(), w.start/60, w.start%60, 0, 0, loc)
+	if !opening.After(local) {
+		opening = time.Date(local.Year(), local.Month(), local.Day()+1, w.start/60, w.start%60, 0, 0, loc)
+	}
+
+	return opening, false
+}
+
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+
+	return t.Hour()*60 + t.Minute(), nil
+}
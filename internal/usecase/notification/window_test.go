@@ -0,0 +1,139 @@
+package notification
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSendWindowNextOpening(t *testing.T) {
+	almaty := mustLoadLocation(t, "Asia/Almaty")
+	berlin := mustLoadLocation(t, "Europe/Berlin")
+
+	tests := []struct {
+		name       string
+		start, end string
+		loc        *time.Location
+		now        time.Time
+		wantInside bool
+		want       time.Time
+	}{
+		{
+			name: "inside", start: "10:00", end: "21:00", loc: almaty,
+			now:        time.Date(2026, 10, 16, 12, 0, 0, 0, almaty),
+			wantInside: true,
+		},
+		{
+			name: "before opening", start: "10:00", end: "21:00", loc: almaty,
+			now:  time.Date(2026, 10, 16, 9, 59, 0, 0, almaty),
+			want: time.Date(2026, 10, 16, 10, 0, 0, 0, almaty),
+		},
+		{
+			name: "end is exclusive", start: "10:00", end: "21:00", loc: almaty,
+			now:  time.Date(2026, 10, 16, 21, 0, 0, 0, almaty),
+			want: time.Date(2026, 10, 17, 10, 0, 0, 0, almaty),
+		},
+		{
+			name: "after closing on the last day of the month", start: "10:00", end: "21:00", loc: almaty,
+			now:  time.Date(2026, 10, 31, 23, 30, 0, 0, almaty),
+			want: time.Date(2026, 11, 1, 10, 0, 0, 0, almaty),
+		},
+		{
+			name: "customer timezone, not the one of now", start: "10:00", end: "21:00", loc: almaty,
+			// 03:00 UTC is 08:00 in Almaty
+			now:  time.Date(2026, 10, 16, 3, 0, 0, 0, time.UTC),
+			want: time.Date(2026, 10, 16, 10, 0, 0, 0, almaty),
+		},
+		{
+			name: "wrapping midnight, inside before midnight", start: "22:00", end: "06:00", loc: almaty,
+			now:        time.Date(2026, 10, 16, 23, 0, 0, 0, almaty),
+			wantInside: true,
+		},
+		{
+			name: "wrapping midnight, inside after midnight", start: "22:00", end: "06:00", loc: almaty,
+			now:        time.Date(2026, 10, 16, 5, 59, 0, 0, almaty),
+			wantInside: true,
+		},
+		{
+			name: "wrapping midnight, closed", start: "22:00", end: "06:00", loc: almaty,
+			now:  time.Date(2026, 10, 16, 6, 0, 0, 0, almaty),
+			want: time.Date(2026, 10, 16, 22, 0, 0, 0, almaty),
+		},
+		{
+			name: "empty window", start: "10:00", end: "10:00", loc: almaty,
+			now:        time.Date(2026, 10, 16, 3, 0, 0, 0, almaty),
+			wantInside: true,
+		},
+		{
+			name: "night before the clocks go forward", start: "10:00", end: "21:00", loc: berlin,
+			now:  time.Date(2026, 3, 28, 22, 0, 0, 0, berlin),
+			want: time.Date(2026, 3, 29, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "night before the clocks go back", start: "10:00", end: "21:00", loc: berlin,
+			now:  time.Date(2026, 10, 24, 22, 0, 0, 0, berlin),
+			want: time.Date(2026, 10, 25, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "wrapping midnight on the day the clocks go forward", start: "22:00", end: "06:00", loc: berlin,
+			now:  time.Date(2026, 3, 29, 7, 0, 0, 0, berlin),
+			want: time.Date(2026, 3, 29, 20, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := NewSendWindow(tt.start, tt.end, "Asia/Almaty")
+			if err != nil {
+				t.Fatalf("NewSendWindow: %v", err)
+			}
+
+			got, inside := w.NextOpening(tt.now, tt.loc)
+			if inside != tt.wantInside {
+				t.Fatalf("inside = %v, want %v", inside, tt.wantInside)
+			}
+
+			want := tt.want
+			if tt.wantInside {
+				want = tt.now
+			}
+			if !got.Equal(want) {
+				t.Errorf("opening = %s, want %s", got, want.In(tt.loc))
+			}
+		})
+	}
+}
+
+func TestSendWindowLocation(t *testing.T) {
+	w, err := NewSendWindow("10:00", "21:00", "Asia/Almaty")
+	if err != nil {
+		t.Fatalf("NewSendWindow: %v", err)
+	}
+
+	tests := []struct {
+		phone, timezone string
+		want            string
+	}{
+		{"+77011234567", "", "Asia/Almaty"},
+		{"+79161234567", "", "Europe/Moscow"},
+		{"+77011234567", "Asia/Aqtau", "Asia/Aqtau"},
+		{"+77011234567", "Not/AZone", "Asia/Almaty"},
+		{"+70001234567", "", "Asia/Almaty"},
+	}
+
+	for _, tt := range tests {
+		if got := w.Location(tt.phone, tt.timezone).String(); got != tt.want {
+			t.Errorf("Location(%s, %q) = %s, want %s", tt.phone, tt.timezone, got, tt.want)
+		}
+	}
+}
+
+func mustLoadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatalf("time.LoadLocation(%s): %v", name, err)
+	}
+
+	return loc
+}
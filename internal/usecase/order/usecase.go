@@ -1,21 +1,289 @@
 package order
 
-import "context"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	customerModel "mb-feedback/internal/domain/customer/model"
+	orderModel "mb-feedback/internal/domain/order/model"
+	syncCursorModel "mb-feedback/internal/domain/sync_cursor/model"
+	"mb-feedback/internal/errs"
+	"time"
+)
 
 type OrderServiceI interface {
-	FetchOrdersFromExternalSource(ctx context.Context) error
+	FetchOrdersFromExternalSource(ctx context.Context, provider *orderModel.Provider, completedAfter *time.Time) ([]*orderModel.Order, []*orderModel.Order, error)
+	UpsertOrders(ctx context.Context, orders []*orderModel.Order) ([]*orderModel.Order, error)
+	SyncStatusFromExternalSource(ctx context.Context, provider *orderModel.Provider, status string, completedAfter *time.Time) (int64, error)
+}
+
+type SyncCursorServiceI interface {
+	Get(ctx context.Context, pars *syncCursorModel.GetPars, errNE bool) (*syncCursorModel.SyncCursor, bool, error)
+	List(ctx context.Context, pars *syncCursorModel.ListPars) ([]*syncCursorModel.SyncCursor, int64, error)
+	Set(ctx context.Context, obj *syncCursorModel.Edit) error
+	Reset(ctx context.Context, pars *syncCursorModel.GetPars) error
+}
+
+type CustomerServiceI interface {
+	Sync(ctx context.Context, objs []*customerModel.Edit) error
+	RelinkOrders(ctx context.Context) (int64, error)
 }
 
 type Usecase struct {
-	orderService OrderServiceI
+	orderService      OrderServiceI
+	syncCursorService SyncCursorServiceI
+	customerService   CustomerServiceI
+
+	providers          []*orderModel.Provider
+	syncOverlap        time.Duration
+	statusChangeWindow time.Duration
 }
 
-func New(orderService OrderServiceI) *Usecase {
+// New creates the usecase. statusChangeWindow limits how far back orders are checked
+// for status changes such as returns and cancellations.
+func New(
+	orderService OrderServiceI,
+	syncCursorService SyncCursorServiceI,
+	customerService CustomerServiceI,
+	providers []*orderModel.Provider,
+	syncOverlap time.Duration,
+	statusChangeWindow time.Duration) *Usecase {
 	return &Usecase{
-		orderService: orderService,
+		orderService:       orderService,
+		syncCursorService:  syncCursorService,
+		customerService:    customerService,
+		providers:          providers,
+		syncOverlap:        syncOverlap,
+		statusChangeWindow: statusChangeWindow,
 	}
 }
 
+// FetchNewOrders imports new orders of every configured provider and
+// picks up status changes of the already imported ones.
+// A failing provider does not stop the import of the others.
+// Every run ends with a catch-up pass linking orders to their customers.
 func (u *Usecase) FetchNewOrders(ctx context.Context) error {
-	return u.orderService.FetchOrdersFromExternalSource(ctx)
+	var result error
+
+	for _, provider := range u.providers {
+		if err := u.fetchProviderOrders(ctx, provider); err != nil {
+			result = errors.Join(result, fmt.Errorf("provider %s: %w", provider.ID, err))
+		}
+
+		if err := u.syncProviderStatuses(ctx, provider); err != nil {
+			result = errors.Join(result, fmt.Errorf("provider %s: %w", provider.ID, err))
+		}
+	}
+
+	if linked, err := u.customerService.RelinkOrders(ctx); err != nil {
+		slog.Error("Failed to relink orders to customers", "error", err)
+	} else if linked > 0 {
+		slog.Info("Orders relinked to customers", "count", linked)
+	}
+
+	return result
+}
+
+// syncProviderStatuses moves orders completed within statusChangeWindow to their
+// new status, e.g. RETURNED or CANCELLED, so no feedback is requested for them.
+func (u *Usecase) syncProviderStatuses(ctx context.Context, provider *orderModel.Provider) error {
+	completedAfter := time.Now().Add(-u.statusChangeWindow)
+
+	for _, status := range provider.StatusChanges {
+		changed, err := u.orderService.SyncStatusFromExternalSource(ctx, provider, status, &completedAfter)
+		if err != nil {
+			return fmt.Errorf("failed to sync %s orders: %w", status, err)
+		}
+
+		if changed > 0 {
+			slog.Info("Order statuses changed", "provider", provider.ID, "status", status, "count", changed)
+		}
+	}
+
+	return nil
+}
+
+// fetchProviderOrders imports provider orders completed since the provider sync cursor.
+// The window is widened by syncOverlap to catch orders that arrived late in the
+// external source; those duplicates are filtered out by the order service.
+// A fetch truncated by the source page limit moves the cursor only as far as the
+// orders actually received, and only if they came oldest first, so the rest is
+// picked up by the next run. If the cursor cannot move, the truncation is returned.
+func (u *Usecase) fetchProviderOrders(ctx context.Context, provider *orderModel.Provider) error {
+	cursor, found, err := u.syncCursorService.Get(ctx, &syncCursorModel.GetPars{Provider: provider.ID}, false)
+	if err != nil {
+		return fmt.Errorf("failed to get sync cursor: %w", err)
+	}
+
+	var completedAfter *time.Time
+	if found {
+		from := cursor.LastTs.Add(-u.syncOverlap)
+		completedAfter = &from
+	}
+
+	orders, insertedOrders, err := u.orderService.FetchOrdersFromExternalSource(ctx, provider, completedAfter)
+	truncated := errors.Is(err, errs.Truncated)
+	if err != nil && !truncated {
+		return err
+	}
+
+	u.syncCustomers(ctx, insertedOrders)
+
+	if truncated && !isCompletionOrdered(orders) {
+		return fmt.Errorf("sync cursor not moved, truncated fetch is not ordered by completion time: %w", err)
+	}
+
+	last := lastOrder(orders)
+	if last == nil || (found && !isAfterCursor(last, cursor)) {
+		if truncated {
+			return fmt.Errorf("sync cursor not moved: %w", err)
+		}
+		return nil
+	}
+
+	if err = u.syncCursorService.Set(ctx, &syncCursorModel.Edit{
+		Provider:    provider.ID,
+		LastTs:      &last.CompletedAt,
+		LastOrderID: &last.ExternalOrderID,
+	}); err != nil {
+		return fmt.Errorf("failed to move sync cursor: %w", err)
+	}
+
+	slog.Info("Sync cursor moved", "provider", provider.ID, "lastTs", last.CompletedAt, "lastOrderID", last.ExternalOrderID)
+
+	if truncated {
+		slog.Warn("Order fetch truncated, the rest is imported by the next run", "provider", provider.ID, "fetched", len(orders), "error", err)
+	}
+
+	return nil
+}
+
+// IngestOrders stores orders pushed by the external source, e.g. through a webhook.
+func (u *Usecase) IngestOrders(ctx context.Context, orders []*orderModel.Order) error {
+	for _, order := range orders {
+		if !u.isKnownProvider(order.Provider) {
+			return fmt.Errorf("%w: unknown provider %s", errs.InvalidInput, order.Provider)
+		}
+	}
+
+	upsertedOrders, err := u.orderService.UpsertOrders(ctx, orders)
+	if err != nil {
+		return err
+	}
+
+	u.syncCustomers(ctx, upsertedOrders)
+
+	return nil
+}
+
+// syncCustomers updates customer profiles from the stored orders.
+// Orders are already saved at this point, so a failure is only logged.
+func (u *Usecase) syncCustomers(ctx context.Context, orders []*orderModel.Order) {
+	if len(orders) == 0 {
+		return
+	}
+
+	customers := make([]*customerModel.Edit, 0, len(orders))
+	for _, order := range orders {
+		customer := &customerModel.Edit{
+			Phone:     order.UserPhone,
+			FirstName: &order.UserName,
+			LastName:  &order.UserLastName,
+		}
+		if order.UserEmail != "" {
+			customer.Email = &order.UserEmail
+		}
+		if !order.CompletedAt.IsZero() {
+			customer.SeenAt = &order.CompletedAt
+		}
+
+		customers = append(customers, customer)
+	}
+
+	if err := u.customerService.Sync(ctx, customers); err != nil {
+		slog.Error("Failed to sync customers", "error", err)
+	}
+}
+
+// ListSyncCursors returns the current position of all provider sync cursors.
+func (u *Usecase) ListSyncCursors(ctx context.Context) ([]*syncCursorModel.SyncCursor, error) {
+	result, _, err := u.syncCursorService.List(ctx, &syncCursorModel.ListPars{})
+	return result, err
+}
+
+// RewindSyncCursor moves the provider sync cursor to the given position,
+// so the next import re-reads everything completed after it.
+func (u *Usecase) RewindSyncCursor(ctx context.Context, provider string, lastTs time.Time, lastOrderID string) error {
+	if !u.isKnownProvider(provider) {
+		return errs.InvalidInput
+	}
+
+	return u.syncCursorService.Set(ctx, &syncCursorModel.Edit{
+		Provider:    provider,
+		LastTs:      &lastTs,
+		LastOrderID: &lastOrderID,
+	})
+}
+
+// ResetSyncCursor drops the provider sync cursor, so the next import does a full fetch.
+func (u *Usecase) ResetSyncCursor(ctx context.Context, provider string) error {
+	if !u.isKnownProvider(provider) {
+		return errs.InvalidInput
+	}
+
+	return u.syncCursorService.Reset(ctx, &syncCursorModel.GetPars{Provider: provider})
+}
+
+func (u *Usecase) isKnownProvider(providerID string) bool {
+	for _, provider := range u.providers {
+		if provider.ID == providerID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// lastOrder returns the order with the greatest completion time,
+// using the external order ID to break ties.
+func lastOrder(orders []*orderModel.Order) *orderModel.Order {
+	var result *orderModel.Order
+	for _, order := range orders {
+		if order.CompletedAt.IsZero() {
+			continue
+		}
+		if result == nil ||
+			order.CompletedAt.After(result.CompletedAt) ||
+			(order.CompletedAt.Equal(result.CompletedAt) && order.ExternalOrderID > result.ExternalOrderID) {
+			result = order
+		}
+	}
+
+	return result
+}
+
+// isCompletionOrdered reports whether orders come oldest completion first,
+// i.e. nothing older than the last of them can still be missing.
+func isCompletionOrdered(orders []*orderModel.Order) bool {
+	var prev time.Time
+	for _, order := range orders {
+		if order.CompletedAt.IsZero() {
+			continue
+		}
+		if order.CompletedAt.Before(prev) {
+			return false
+		}
+		prev = order.CompletedAt
+	}
+
+	return true
+}
+
+func isAfterCursor(order *orderModel.Order, cursor *syncCursorModel.SyncCursor) bool {
+	if order.CompletedAt.Equal(cursor.LastTs) {
+		return order.ExternalOrderID > cursor.LastOrderID
+	}
+
+	return order.CompletedAt.After(cursor.LastTs)
 }
@@ -0,0 +1,190 @@
+package order
+
+import (
+	"context"
+	"mb-feedback/internal/client/breaker"
+	"mb-feedback/internal/client/cassette"
+	mb_broker "mb-feedback/internal/client/fetcher/mb-broker"
+	customerModel "mb-feedback/internal/domain/customer/model"
+	orderModel "mb-feedback/internal/domain/order/model"
+	orderRepoFetcher "mb-feedback/internal/domain/order/repo/fetcher"
+	orderService "mb-feedback/internal/domain/order/service"
+	syncCursorModel "mb-feedback/internal/domain/sync_cursor/model"
+	"mb-feedback/internal/errs"
+	"testing"
+	"time"
+)
+
+// testdata/cassettes/mb-broker.json was recorded against the fake mb-broker
+// (go run ./cmd/fakebroker) with http_cassette_mode=record, mb_broker_auth_mode=oauth2
+// and mb_broker_page_size=2: an OAuth2 token exchange and 5 completed kaspi orders
+// on 3 pages. Replay ignores the host, so any base URL works.
+
+func TestFetchNewOrdersReplay(t *testing.T) {
+	repo := &fakeOrderRepo{}
+	cursors := &fakeSyncCursorService{}
+	customers := &fakeCustomerService{}
+
+	usc := newReplayUsecase(t, 0, repo, cursors, customers)
+
+	if err := usc.FetchNewOrders(context.Background()); err != nil {
+		t.Fatalf("FetchNewOrders: %v", err)
+	}
+
+	wantIDs := []string{"5264000003", "8509000004", "8045000005", "6367000002", "7584000001"}
+	if len(repo.created) != len(wantIDs) {
+		t.Fatalf("inserted %d orders, want %d", len(repo.created), len(wantIDs))
+	}
+	for i, obj := range repo.created {
+		if obj.ExternalOrderID != wantIDs[i] {
+			t.Errorf("order %d = %s, want %s", i, obj.ExternalOrderID, wantIDs[i])
+		}
+		if obj.Provider != "kaspi" {
+			t.Errorf("order %s provider = %s, want kaspi", obj.ExternalOrderID, obj.Provider)
+		}
+	}
+	if got := *repo.created[3].UserPhone; got != "+77779391527" {
+		t.Errorf("normalized phone = %s, want +77779391527", got)
+	}
+
+	assertCursor(t, cursors, time.Date(2026, 10, 16, 15, 52, 20, 0, time.UTC), "7584000001")
+
+	if len(customers.synced) != len(wantIDs) {
+		t.Errorf("synced %d customers, want %d", len(customers.synced), len(wantIDs))
+	}
+	if customers.relinked != 1 {
+		t.Errorf("relinked %d times, want 1", customers.relinked)
+	}
+}
+
+func TestFetchNewOrdersTruncatedReplay(t *testing.T) {
+	repo := &fakeOrderRepo{}
+	cursors := &fakeSyncCursorService{}
+
+	// one page fits the first 2 orders only, the rest is left for the next run
+	usc := newReplayUsecase(t, 1, repo, cursors, &fakeCustomerService{})
+
+	if err := usc.FetchNewOrders(context.Background()); err != nil {
+		t.Fatalf("FetchNewOrders: %v", err)
+	}
+
+	if len(repo.created) != 2 {
+		t.Fatalf("inserted %d orders, want 2", len(repo.created))
+	}
+
+	assertCursor(t, cursors, time.Date(2026, 10, 14, 10, 40, 35, 0, time.UTC), "8509000004")
+}
+
+func newReplayUsecase(t *testing.T, maxPages int, repo *fakeOrderRepo, cursors *fakeSyncCursorService, customers *fakeCustomerService) *Usecase {
+	t.Helper()
+
+	transport, err := cassette.New("testdata/cassettes", "mb-broker", cassette.ModeReplay, nil)
+	if err != nil {
+		t.Fatalf("cassette.New: %v", err)
+	}
+
+	tokens := mb_broker.NewOAuth2Token(mb_broker.OAuth2Config{
+		TokenURL:     "http://mb-broker.test/oauth/token",
+		ClientID:     "mb-feedback",
+		ClientSecret: "test-secret",
+	}, transport)
+
+	client := mb_broker.New(
+		"http://mb-broker.test",
+		tokens,
+		2,
+		maxPages,
+		mb_broker.RetryPolicy{},
+		breaker.New("mb-broker", 0, 0, 0),
+		transport,
+		0)
+
+	return New(
+		orderService.New(repo, orderRepoFetcher.New(client)),
+		cursors,
+		customers,
+		[]*orderModel.Provider{{ID: "kaspi"}},
+		time.Hour,
+		24*time.Hour)
+}
+
+func assertCursor(t *testing.T, cursors *fakeSyncCursorService, lastTs time.Time, lastOrderID string) {
+	t.Helper()
+
+	if cursors.set == nil {
+		t.Fatalf("sync cursor not moved")
+	}
+	if cursors.set.Provider != "kaspi" || !cursors.set.LastTs.Equal(lastTs) || *cursors.set.LastOrderID != lastOrderID {
+		t.Errorf("sync cursor = %s %s %s, want kaspi %s %s",
+			cursors.set.Provider, cursors.set.LastTs, *cursors.set.LastOrderID, lastTs, lastOrderID)
+	}
+}
+
+// fakeOrderRepo keeps inserted orders in memory. Methods the import does not use
+// are left to the embedded nil interface and panic if called.
+type fakeOrderRepo struct {
+	orderService.RepoDBI
+
+	created []*orderModel.Edit
+}
+
+func (r *fakeOrderRepo) List(_ context.Context, pars *orderModel.ListPars) ([]*orderModel.Order, int64, error) {
+	var result []*orderModel.Order
+	for _, obj := range r.created {
+		if pars.Provider != nil && obj.Provider != *pars.Provider {
+			continue
+		}
+		for _, id := range *pars.ExternalOrderIDs {
+			if obj.ExternalOrderID == id {
+				result = append(result, &orderModel.Order{Provider: obj.Provider, ExternalOrderID: obj.ExternalOrderID})
+			}
+		}
+	}
+
+	return result, int64(len(result)), nil
+}
+
+func (r *fakeOrderRepo) CreateBatch(_ context.Context, objects []*orderModel.Edit) error {
+	r.created = append(r.created, objects...)
+	return nil
+}
+
+type fakeSyncCursorService struct {
+	set *syncCursorModel.Edit
+}
+
+func (s *fakeSyncCursorService) Get(_ context.Context, _ *syncCursorModel.GetPars, errNE bool) (*syncCursorModel.SyncCursor, bool, error) {
+	if errNE {
+		return nil, false, errs.ObjectNotFound
+	}
+	return nil, false, nil
+}
+
+func (s *fakeSyncCursorService) List(context.Context, *syncCursorModel.ListPars) ([]*syncCursorModel.SyncCursor, int64, error) {
+	return nil, 0, nil
+}
+
+func (s *fakeSyncCursorService) Set(_ context.Context, obj *syncCursorModel.Edit) error {
+	s.set = obj
+	return nil
+}
+
+func (s *fakeSyncCursorService) Reset(context.Context, *syncCursorModel.GetPars) error {
+	s.set = nil
+	return nil
+}
+
+type fakeCustomerService struct {
+	synced   []*customerModel.Edit
+	relinked int
+}
+
+func (s *fakeCustomerService) Sync(_ context.Context, objs []*customerModel.Edit) error {
+	s.synced = append(s.synced, objs...)
+	return nil
+}
+
+func (s *fakeCustomerService) RelinkOrders(context.Context) (int64, error) {
+	s.relinked++
+	return 0, nil
+}
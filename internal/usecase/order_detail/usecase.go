@@ -3,61 +3,186 @@ package order_detail
 import (
 	"context"
 	"fmt"
+	"log/slog"
+	feedbackDelayModel "mb-feedback/internal/domain/feedback_delay/model"
 	orderModel "mb-feedback/internal/domain/order/model"
 	orderDetailModel "mb-feedback/internal/domain/order_detail/model"
+	productModel "mb-feedback/internal/domain/product/model"
+	"mb-feedback/internal/errs"
+	"strings"
+	"sync"
+	"time"
 )
 
 type OrderServiceI interface {
 	ListOrdersWithoutDetails(ctx context.Context, pars *orderModel.ListPars) ([]*orderModel.Order, error)
+	MarkItemsFetched(ctx context.Context, id string) error
 }
 
 type OrderDetailServiceI interface {
-	FetchProductCodesByOrder(ctx context.Context, externalOrderID string) ([]string, error)
+	FetchItemsByOrder(ctx context.Context, provider, externalOrderID string) ([]*orderDetailModel.OrderDetail, error)
 	CreateList(ctx context.Context, objs []*orderDetailModel.Edit) error
 }
 
+type FeedbackDelayServiceI interface {
+	ScheduledAt(ctx context.Context, completedAt time.Time, category string) (time.Time, error)
+	List(ctx context.Context, pars *feedbackDelayModel.ListPars) ([]*feedbackDelayModel.FeedbackDelay, int64, error)
+	Set(ctx context.Context, obj *feedbackDelayModel.Edit) error
+	Delete(ctx context.Context, pars *feedbackDelayModel.GetPars) error
+}
+
+type ProductServiceI interface {
+	Catalog(ctx context.Context, productCodes []string) (map[string]*productModel.Product, error)
+	List(ctx context.Context, pars *productModel.ListPars) ([]*productModel.Product, int64, error)
+	SetList(ctx context.Context, objs []*productModel.Edit) error
+	Delete(ctx context.Context, pars *productModel.GetPars) error
+}
+
 type Usecase struct {
-	orderService       OrderServiceI
-	orderDetailService OrderDetailServiceI
+	orderService         OrderServiceI
+	orderDetailService   OrderDetailServiceI
+	feedbackDelayService FeedbackDelayServiceI
+	productService       ProductServiceI
+
+	workers int
+}
+
+// FetchSummary is the outcome of a FetchProductCodes run.
+type FetchSummary struct {
+	Total     int
+	Succeeded int
+	Failed    int
+	// Failures maps "provider/external order ID" to the error the order failed with.
+	Failures map[string]string
 }
 
-func New(orderService OrderServiceI, orderDetailService OrderDetailServiceI) *Usecase {
+// New creates the usecase. workers is the number of orders processed in parallel,
+// the request rate is capped by the order sources themselves. Item data the order
+// source does not return is taken from the product catalog.
+func New(
+	orderService OrderServiceI,
+	orderDetailService OrderDetailServiceI,
+	workers int,
+	feedbackDelayService FeedbackDelayServiceI,
+	productService ProductServiceI) *Usecase {
+	if workers <= 0 {
+		workers = 1
+	}
+
 	return &Usecase{
-		orderService:       orderService,
-		orderDetailService: orderDetailService,
+		orderService:         orderService,
+		orderDetailService:   orderDetailService,
+		feedbackDelayService: feedbackDelayService,
+		productService:       productService,
+		workers:              workers,
 	}
 }
 
-func (u *Usecase) FetchProductCodes(ctx context.Context) error {
+// FetchProductCodes fetches line items for every order that has none yet.
+// Orders are processed by a pool of workers; a failing order does not stop the others.
+func (u *Usecase) FetchProductCodes(ctx context.Context) (*FetchSummary, error) {
 	//createdAfter := time.Now().Add(-time.Hour) // заказы за крайний час
 
 	missingOrders, err := u.orderService.ListOrdersWithoutDetails(ctx, &orderModel.ListPars{
 		//CreatedAfter: &createdAfter,
 	})
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	summary := &FetchSummary{
+		Total:    len(missingOrders),
+		Failures: make(map[string]string),
+	}
+	if len(missingOrders) == 0 {
+		return summary, nil
+	}
+
+	jobs := make(chan *orderModel.Order)
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+
+	for range min(u.workers, len(missingOrders)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for missingOrder := range jobs {
+				err := ctx.Err()
+				if err == nil {
+					err = u.processMissingOrder(ctx, missingOrder)
+				}
+
+				mu.Lock()
+				if err != nil {
+					slog.Error("Failed to process missing order", "provider", missingOrder.Provider, "orderID", missingOrder.ExternalOrderID, "error", err)
+					summary.Failed++
+					summary.Failures[missingOrder.Provider+"/"+missingOrder.ExternalOrderID] = err.Error()
+				} else {
+					summary.Succeeded++
+				}
+				mu.Unlock()
+			}
+		}()
 	}
 
 	for _, missingOrder := range missingOrders {
-		if err = u.processMissingOrder(ctx, missingOrder); err != nil {
-			return fmt.Errorf("failed to process missing order %s: %w", missingOrder.ExternalOrderID, err)
-		}
+		jobs <- missingOrder
 	}
+	close(jobs)
 
-	return nil
+	wg.Wait()
+
+	return summary, nil
 }
 
+// processMissingOrder stores the order items completed from the product catalog,
+// each scheduled for feedback after the delay of its category since the order completion.
+// An order without items is marked as fetched, so it is not requested again.
 func (u *Usecase) processMissingOrder(ctx context.Context, missingOrder *orderModel.Order) error {
-	productCodes, err := u.orderDetailService.FetchProductCodesByOrder(ctx, missingOrder.ExternalOrderID)
+	items, err := u.orderDetailService.FetchItemsByOrder(ctx, missingOrder.Provider, missingOrder.ExternalOrderID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch items for order %s: %w", missingOrder.ExternalOrderID, err)
+	}
+	if len(items) == 0 {
+		slog.Info("Order has no items", "provider", missingOrder.Provider, "orderID", missingOrder.ExternalOrderID)
+
+		if err = u.orderService.MarkItemsFetched(ctx, missingOrder.ID); err != nil {
+			return fmt.Errorf("failed to mark order %s as fetched: %w", missingOrder.ExternalOrderID, err)
+		}
+		return nil
+	}
+
+	productCodes := make([]string, 0, len(items))
+	for _, item := range items {
+		productCodes = append(productCodes, item.ProductCode)
+	}
+
+	catalog, err := u.productService.Catalog(ctx, productCodes)
 	if err != nil {
-		return fmt.Errorf("failed to fetch product codes for order %s: %w", missingOrder.ExternalOrderID, err)
+		return fmt.Errorf("failed to get products of order %s: %w", missingOrder.ExternalOrderID, err)
 	}
 
-	orderDetailEdit := make([]*orderDetailModel.Edit, 0, len(productCodes))
-	for _, productCode := range productCodes {
+	orderDetailEdit := make([]*orderDetailModel.Edit, 0, len(items))
+	for _, item := range items {
+		fillFromCatalog(item, catalog[item.ProductCode])
+
+		scheduledAt, err := u.feedbackDelayService.ScheduledAt(ctx, missingOrder.CompletedAt, item.Category)
+		if err != nil {
+			return fmt.Errorf("failed to schedule feedback for order %s: %w", missingOrder.ExternalOrderID, err)
+		}
+
 		orderDetailEdit = append(orderDetailEdit, &orderDetailModel.Edit{
 			OrderID:     missingOrder.ID,
-			ProductCode: &productCode,
+			ProductCode: &item.ProductCode,
+			ProductName: &item.ProductName,
+			Quantity:    &item.Quantity,
+			UnitPrice:   &item.UnitPrice,
+			Category:    &item.Category,
+			MerchantSKU: &item.MerchantSKU,
+			ScheduledAt: &scheduledAt,
 		})
 	}
 
@@ -67,3 +192,72 @@ func (u *Usecase) processMissingOrder(ctx context.Context, missingOrder *orderMo
 
 	return nil
 }
+
+// fillFromCatalog completes the item with the catalog data of its product.
+// Data returned by the order source is kept.
+func fillFromCatalog(item *orderDetailModel.OrderDetail, product *productModel.Product) {
+	if product == nil {
+		return
+	}
+
+	if item.ProductName == "" {
+		item.ProductName = product.ProductName
+	}
+	if item.UnitPrice == 0 {
+		item.UnitPrice = product.UnitPrice
+	}
+	if item.Category == "" {
+		item.Category = product.Category
+	}
+	if item.MerchantSKU == "" {
+		item.MerchantSKU = product.MerchantSKU
+	}
+}
+
+// ListProducts returns the product catalog.
+func (u *Usecase) ListProducts(ctx context.Context) ([]*productModel.Product, error) {
+	result, _, err := u.productService.List(ctx, &productModel.ListPars{})
+	return result, err
+}
+
+// SetProducts adds the products to the catalog or replaces them. Details already stored
+// without product data pick it up when they are notified about, their feedback stays
+// scheduled with the delay they were stored with.
+func (u *Usecase) SetProducts(ctx context.Context, objs []*productModel.Edit) error {
+	seen := make(map[string]struct{}, len(objs))
+	for _, obj := range objs {
+		code := strings.TrimSpace(obj.ProductCode)
+		if _, ok := seen[code]; ok {
+			return fmt.Errorf("%w: product_code %s repeats", errs.InvalidInput, code)
+		}
+		seen[code] = struct{}{}
+	}
+
+	return u.productService.SetList(ctx, objs)
+}
+
+// DeleteProduct removes the product from the catalog.
+func (u *Usecase) DeleteProduct(ctx context.Context, productCode string) error {
+	return u.productService.Delete(ctx, &productModel.GetPars{ProductCode: productCode})
+}
+
+// ListFeedbackDelays returns the feedback delay rules by product category.
+func (u *Usecase) ListFeedbackDelays(ctx context.Context) ([]*feedbackDelayModel.FeedbackDelay, error) {
+	result, _, err := u.feedbackDelayService.List(ctx, &feedbackDelayModel.ListPars{})
+	return result, err
+}
+
+// SetFeedbackDelay changes how long after the order completion feedback on products
+// of the category is requested. It applies to order details stored from now on.
+func (u *Usecase) SetFeedbackDelay(ctx context.Context, obj *feedbackDelayModel.Edit) error {
+	if obj.Delay < 0 {
+		return fmt.Errorf("%w: delay must not be negative", errs.InvalidInput)
+	}
+
+	return u.feedbackDelayService.Set(ctx, obj)
+}
+
+// DeleteFeedbackDelay removes the category rule, its products fall back to the default delay.
+func (u *Usecase) DeleteFeedbackDelay(ctx context.Context, category string) error {
+	return u.feedbackDelayService.Delete(ctx, &feedbackDelayModel.GetPars{Category: category})
+}
@@ -0,0 +1,282 @@
+package order_import
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"io"
+	"log/slog"
+	"mb-feedback/internal/client/fetcher/file"
+	"mb-feedback/internal/cns"
+	customerModel "mb-feedback/internal/domain/customer/model"
+	orderModel "mb-feedback/internal/domain/order/model"
+	orderDetailModel "mb-feedback/internal/domain/order_detail/model"
+	productModel "mb-feedback/internal/domain/product/model"
+	"mb-feedback/internal/errs"
+	"slices"
+	"time"
+)
+
+type OrderServiceI interface {
+	FormatPhoneNumber(phone string) (string, error)
+	CreateIfNotExistsTx(ctx context.Context, tx pgx.Tx, obj *orderModel.Edit) (string, bool, error)
+}
+
+type OrderDetailServiceI interface {
+	CreateListTx(ctx context.Context, tx pgx.Tx, objs []*orderDetailModel.Edit) error
+	BeginTx(ctx context.Context) (pgx.Tx, error)
+	HandleTxCompletion(tx pgx.Tx, err *error)
+}
+
+type CustomerServiceI interface {
+	Sync(ctx context.Context, objs []*customerModel.Edit) error
+}
+
+type FeedbackDelayServiceI interface {
+	ScheduledAt(ctx context.Context, completedAt time.Time, category string) (time.Time, error)
+}
+
+type ProductServiceI interface {
+	Catalog(ctx context.Context, productCodes []string) (map[string]*productModel.Product, error)
+}
+
+type Usecase struct {
+	orderService         OrderServiceI
+	orderDetailService   OrderDetailServiceI
+	customerService      CustomerServiceI
+	feedbackDelayService FeedbackDelayServiceI
+	productService       ProductServiceI
+
+	providers []string
+}
+
+// New creates the usecase. providers lists the configured provider IDs orders may be imported for.
+// Files carry product codes only, the rest of the item data is taken from the product catalog.
+func New(
+	orderService OrderServiceI,
+	orderDetailService OrderDetailServiceI,
+	customerService CustomerServiceI,
+	feedbackDelayService FeedbackDelayServiceI,
+	providers []string,
+	productService ProductServiceI) *Usecase {
+	return &Usecase{
+		orderService:         orderService,
+		orderDetailService:   orderDetailService,
+		customerService:      customerService,
+		feedbackDelayService: feedbackDelayService,
+		productService:       productService,
+		providers:            providers,
+	}
+}
+
+// RowResult is the import outcome of a single file row.
+type RowResult struct {
+	Line            int    `json:"line"`
+	ExternalOrderID string `json:"external_order_id"`
+	Status          string `json:"status"`
+	Reason          string `json:"reason,omitempty"`
+}
+
+// Report is the outcome of an import, with one result per file row.
+type Report struct {
+	Accepted  int          `json:"accepted"`
+	Duplicate int          `json:"duplicate"`
+	Rejected  int          `json:"rejected"`
+	Rows      []*RowResult `json:"rows"`
+}
+
+func (r *Report) add(row *RowResult) {
+	switch row.Status {
+	case cns.ImportRowAccepted:
+		r.Accepted++
+	case cns.ImportRowDuplicate:
+		r.Duplicate++
+	case cns.ImportRowRejected:
+		r.Rejected++
+	}
+
+	r.Rows = append(r.Rows, row)
+}
+
+type validRow struct {
+	row    *file.Row
+	result *RowResult
+	phone  string
+}
+
+// Import reads provider orders in the given format (csv or jsonl) and stores the valid ones
+// together with their product codes. All orders of the file are written in one transaction.
+func (u *Usecase) Import(ctx context.Context, provider string, r io.Reader, format string) (*Report, error) {
+	if provider == "" {
+		return nil, fmt.Errorf("%w: provider is required", errs.InvalidInput)
+	}
+	if !slices.Contains(u.providers, provider) {
+		return nil, fmt.Errorf("%w: unknown provider %s", errs.InvalidInput, provider)
+	}
+
+	rows, err := file.Parse(r, format)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		valid   []*validRow
+		results = make([]*RowResult, 0, len(rows))
+		seen    = make(map[string]struct{}, len(rows))
+	)
+
+	for _, row := range rows {
+		result := &RowResult{
+			Line:            row.Line,
+			ExternalOrderID: row.ExternalOrderID,
+			Status:          cns.ImportRowAccepted,
+		}
+		results = append(results, result)
+
+		if row.Err != nil {
+			result.Status, result.Reason = cns.ImportRowRejected, row.Err.Error()
+			continue
+		}
+
+		phone, err := u.orderService.FormatPhoneNumber(row.Phone)
+		if err != nil {
+			result.Status, result.Reason = cns.ImportRowRejected, err.Error()
+			continue
+		}
+
+		if _, ok := seen[row.ExternalOrderID]; ok {
+			result.Status, result.Reason = cns.ImportRowDuplicate, "repeated in file"
+			continue
+		}
+		seen[row.ExternalOrderID] = struct{}{}
+
+		valid = append(valid, &validRow{row: row, result: result, phone: phone})
+	}
+
+	if len(valid) > 0 {
+		var productCodes []string
+		for _, v := range valid {
+			productCodes = append(productCodes, v.row.ProductCodes...)
+		}
+
+		catalog, err := u.productService.Catalog(ctx, productCodes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get products: %w", err)
+		}
+
+		err = func() (err error) {
+			tx, err := u.orderDetailService.BeginTx(ctx)
+			if err != nil {
+				return fmt.Errorf("failed to begin transaction: %w", err)
+			}
+			defer u.orderDetailService.HandleTxCompletion(tx, &err)
+
+			for _, v := range valid {
+				completedAt := v.row.CompletedAt
+				if completedAt.IsZero() {
+					completedAt = time.Now()
+				}
+
+				orderID, created, err := u.orderService.CreateIfNotExistsTx(ctx, tx, &orderModel.Edit{
+					Provider:        provider,
+					ExternalOrderID: v.row.ExternalOrderID,
+					UserPhone:       &v.phone,
+					UserName:        &v.row.Name,
+					CompletedAt:     &completedAt,
+				})
+				if err != nil {
+					return fmt.Errorf("failed to create order %s: %w", v.row.ExternalOrderID, err)
+				}
+				if !created {
+					v.result.Status, v.result.Reason = cns.ImportRowDuplicate, "already imported"
+					continue
+				}
+
+				details, err := u.newDetails(ctx, orderID, v.row.ProductCodes, completedAt, catalog)
+				if err != nil {
+					return fmt.Errorf("failed to schedule feedback for order %s: %w", v.row.ExternalOrderID, err)
+				}
+
+				if err = u.orderDetailService.CreateListTx(ctx, tx, details); err != nil {
+					return fmt.Errorf("failed to create order details for order %s: %w", v.row.ExternalOrderID, err)
+				}
+			}
+
+			return nil
+		}()
+		if err != nil {
+			return nil, err
+		}
+
+		u.syncCustomers(ctx, valid)
+	}
+
+	report := &Report{}
+	for _, result := range results {
+		report.add(result)
+	}
+
+	return report, nil
+}
+
+// syncCustomers updates customer profiles from the accepted rows.
+// Orders are already committed at this point, so a failure is only logged.
+func (u *Usecase) syncCustomers(ctx context.Context, rows []*validRow) {
+	customers := make([]*customerModel.Edit, 0, len(rows))
+	for _, v := range rows {
+		if v.result.Status != cns.ImportRowAccepted {
+			continue
+		}
+
+		customer := &customerModel.Edit{
+			Phone:     v.phone,
+			FirstName: &v.row.Name,
+		}
+		if !v.row.CompletedAt.IsZero() {
+			customer.SeenAt = &v.row.CompletedAt
+		}
+
+		customers = append(customers, customer)
+	}
+
+	if err := u.customerService.Sync(ctx, customers); err != nil {
+		slog.Error("Failed to sync customers", "error", err)
+	}
+}
+
+// newDetails builds the order details of the product codes with the catalog data of the products,
+// each scheduled for feedback after the delay of its category since the order completion.
+func (u *Usecase) newDetails(
+	ctx context.Context,
+	orderID string,
+	productCodes []string,
+	completedAt time.Time,
+	catalog map[string]*productModel.Product) ([]*orderDetailModel.Edit, error) {
+	quantity := 1
+
+	result := make([]*orderDetailModel.Edit, 0, len(productCodes))
+	for _, productCode := range productCodes {
+		product := catalog[productCode]
+		if product == nil {
+			product = &productModel.Product{ProductCode: productCode}
+		}
+
+		scheduledAt, err := u.feedbackDelayService.ScheduledAt(ctx, completedAt, product.Category)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, &orderDetailModel.Edit{
+			OrderID:     orderID,
+			ProductCode: &product.ProductCode,
+			ProductName: &product.ProductName,
+			Quantity:    &quantity,
+			UnitPrice:   &product.UnitPrice,
+			Category:    &product.Category,
+			MerchantSKU: &product.MerchantSKU,
+			ScheduledAt: &scheduledAt,
+		})
+	}
+
+	return result, nil
+}
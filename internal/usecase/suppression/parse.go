@@ -0,0 +1,74 @@
+package suppression
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"mb-feedback/internal/errs"
+	"strings"
+)
+
+// row is a single phone number read from an uploaded CSV file.
+// Err is set when the row is malformed; other rows are still returned.
+type row struct {
+	Line   int
+	Phone  string
+	Reason string
+	Source string
+	Err    error
+}
+
+// parseCSV reads rows from CSV with a header line. The phone column is required,
+// reason and source are optional.
+func parseCSV(r io.Reader) ([]*row, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read csv header: %s", errs.InvalidInput, err.Error())
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["phone"]; !ok {
+		return nil, fmt.Errorf("%w: csv column phone is missing", errs.InvalidInput)
+	}
+
+	cell := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	var result []*row
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				result = append(result, &row{Line: line, Err: err})
+				continue
+			}
+			return nil, err
+		}
+
+		result = append(result, &row{
+			Line:   line,
+			Phone:  cell(record, "phone"),
+			Reason: cell(record, "reason"),
+			Source: cell(record, "source"),
+		})
+	}
+
+	return result, nil
+}
@@ -0,0 +1,200 @@
+package suppression
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mb-feedback/internal/cns"
+	suppressionModel "mb-feedback/internal/domain/suppression/model"
+	"mb-feedback/internal/errs"
+	"strings"
+)
+
+// maxSourceLen is the size of the suppression source column.
+const maxSourceLen = 50
+
+type OrderServiceI interface {
+	FormatPhoneNumber(phone string) (string, error)
+}
+
+type SuppressionServiceI interface {
+	Get(ctx context.Context, pars *suppressionModel.GetPars, errNE bool) (*suppressionModel.Suppression, bool, error)
+	AddList(ctx context.Context, objs []*suppressionModel.Edit) error
+	Remove(ctx context.Context, pars *suppressionModel.GetPars) error
+}
+
+type Usecase struct {
+	orderService       OrderServiceI
+	suppressionService SuppressionServiceI
+}
+
+func New(orderService OrderServiceI, suppressionService SuppressionServiceI) *Usecase {
+	return &Usecase{
+		orderService:       orderService,
+		suppressionService: suppressionService,
+	}
+}
+
+// RowResult is the import outcome of a single file row.
+type RowResult struct {
+	Line   int    `json:"line"`
+	Phone  string `json:"phone"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Report is the outcome of an import, with one result per file row.
+type Report struct {
+	Accepted  int          `json:"accepted"`
+	Duplicate int          `json:"duplicate"`
+	Rejected  int          `json:"rejected"`
+	Rows      []*RowResult `json:"rows"`
+}
+
+func (r *Report) add(row *RowResult) {
+	switch row.Status {
+	case cns.ImportRowAccepted:
+		r.Accepted++
+	case cns.ImportRowDuplicate:
+		r.Duplicate++
+	case cns.ImportRowRejected:
+		r.Rejected++
+	}
+
+	r.Rows = append(r.Rows, row)
+}
+
+// Add puts the phone number on the suppression list, so no more messages are sent to it.
+// An empty source means the number was added through the API.
+func (u *Usecase) Add(ctx context.Context, phone, reason, source string) (*suppressionModel.Suppression, error) {
+	normalized, err := u.normalizePhone(phone)
+	if err != nil {
+		return nil, err
+	}
+
+	if source == "" {
+		source = cns.SuppressionSourceAPI
+	}
+	if len(source) > maxSourceLen {
+		return nil, fmt.Errorf("%w: source is longer than %d characters", errs.InvalidInput, maxSourceLen)
+	}
+
+	if err = u.suppressionService.AddList(ctx, []*suppressionModel.Edit{newEdit(normalized, reason, source)}); err != nil {
+		return nil, err
+	}
+
+	result, _, err := u.suppressionService.Get(ctx, &suppressionModel.GetPars{Phone: normalized}, true)
+	return result, err
+}
+
+// Remove takes the phone number off the suppression list.
+func (u *Usecase) Remove(ctx context.Context, phone string) error {
+	normalized, err := u.normalizePhone(phone)
+	if err != nil {
+		return err
+	}
+
+	return u.suppressionService.Remove(ctx, &suppressionModel.GetPars{Phone: normalized})
+}
+
+// Lookup returns the suppression list entry of the phone number or errs.ObjectNotFound.
+func (u *Usecase) Lookup(ctx context.Context, phone string) (*suppressionModel.Suppression, error) {
+	normalized, err := u.normalizePhone(phone)
+	if err != nil {
+		return nil, err
+	}
+
+	result, _, err := u.suppressionService.Get(ctx, &suppressionModel.GetPars{Phone: normalized}, true)
+	return result, err
+}
+
+// Import reads phone numbers from CSV and puts the valid ones on the suppression list.
+// Rows without a source are recorded as coming from the CSV upload.
+func (u *Usecase) Import(ctx context.Context, r io.Reader) (*Report, error) {
+	rows, err := parseCSV(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		objs    []*suppressionModel.Edit
+		results = make([]*RowResult, 0, len(rows))
+		seen    = make(map[string]struct{}, len(rows))
+	)
+
+	for _, row := range rows {
+		result := &RowResult{
+			Line:   row.Line,
+			Phone:  row.Phone,
+			Status: cns.ImportRowAccepted,
+		}
+		results = append(results, result)
+
+		if row.Err != nil {
+			result.Status, result.Reason = cns.ImportRowRejected, row.Err.Error()
+			continue
+		}
+
+		phone, err := u.orderService.FormatPhoneNumber(trimPhone(row.Phone))
+		if err != nil {
+			result.Status, result.Reason = cns.ImportRowRejected, err.Error()
+			continue
+		}
+		result.Phone = phone
+
+		source := row.Source
+		if source == "" {
+			source = cns.SuppressionSourceCSV
+		}
+		if len(source) > maxSourceLen {
+			result.Status, result.Reason = cns.ImportRowRejected, fmt.Sprintf("source is longer than %d characters", maxSourceLen)
+			continue
+		}
+
+		if _, ok := seen[phone]; ok {
+			result.Status, result.Reason = cns.ImportRowDuplicate, "repeated in file"
+			continue
+		}
+		seen[phone] = struct{}{}
+
+		objs = append(objs, newEdit(phone, row.Reason, source))
+	}
+
+	if err = u.suppressionService.AddList(ctx, objs); err != nil {
+		return nil, fmt.Errorf("failed to add phone numbers to the suppression list: %w", err)
+	}
+
+	report := &Report{}
+	for _, result := range results {
+		report.add(result)
+	}
+
+	return report, nil
+}
+
+// normalizePhone brings the phone number to the format numbers are stored in on orders.
+func (u *Usecase) normalizePhone(phone string) (string, error) {
+	result, err := u.orderService.FormatPhoneNumber(trimPhone(phone))
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", errs.InvalidInput, err.Error())
+	}
+
+	return result, nil
+}
+
+// trimPhone drops the leading plus of numbers already in the international format.
+func trimPhone(phone string) string {
+	return strings.TrimPrefix(strings.TrimSpace(phone), "+")
+}
+
+func newEdit(phone, reason, source string) *suppressionModel.Edit {
+	obj := &suppressionModel.Edit{
+		Phone:  phone,
+		Source: source,
+	}
+	if reason != "" {
+		obj.Reason = &reason
+	}
+
+	return obj
+}
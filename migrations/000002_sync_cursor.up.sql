@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS sync_cursor (
+    provider VARCHAR(50) PRIMARY KEY,                 -- провайдер заказов (kaspi, ...)
+    last_ts TIMESTAMPTZ NOT NULL,                     -- время завершения последнего импортированного заказа
+    last_order_id VARCHAR(50) NOT NULL DEFAULT '',    -- ID последнего импортированного заказа во внешнем сервисе
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,4 @@
+ALTER TABLE ord DROP CONSTRAINT IF EXISTS ord_provider_external_order_id_key;
+ALTER TABLE ord ADD CONSTRAINT ord_external_order_id_key UNIQUE (external_order_id);
+
+ALTER TABLE ord DROP COLUMN IF EXISTS provider;
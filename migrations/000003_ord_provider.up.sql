@@ -0,0 +1,5 @@
+ALTER TABLE ord ADD COLUMN IF NOT EXISTS provider VARCHAR(50) NOT NULL DEFAULT 'kaspi'; -- провайдер заказа в mb-broker
+ALTER TABLE ord ALTER COLUMN provider DROP DEFAULT;
+
+ALTER TABLE ord DROP CONSTRAINT IF EXISTS ord_external_order_id_key;
+ALTER TABLE ord ADD CONSTRAINT ord_provider_external_order_id_key UNIQUE (provider, external_order_id);
@@ -0,0 +1,6 @@
+ALTER TABLE ord_detail
+    DROP COLUMN IF EXISTS product_name,
+    DROP COLUMN IF EXISTS quantity,
+    DROP COLUMN IF EXISTS unit_price,
+    DROP COLUMN IF EXISTS category,
+    DROP COLUMN IF EXISTS merchant_sku;
@@ -0,0 +1,6 @@
+ALTER TABLE ord_detail
+    ADD COLUMN IF NOT EXISTS product_name VARCHAR(255) NOT NULL DEFAULT '',  -- наименование товара
+    ADD COLUMN IF NOT EXISTS quantity INT NOT NULL DEFAULT 1,                -- количество
+    ADD COLUMN IF NOT EXISTS unit_price NUMERIC(14, 2) NOT NULL DEFAULT 0,   -- цена за единицу
+    ADD COLUMN IF NOT EXISTS category VARCHAR(100) NOT NULL DEFAULT '',      -- категория товара
+    ADD COLUMN IF NOT EXISTS merchant_sku VARCHAR(100) NOT NULL DEFAULT '';  -- SKU товара у продавца
@@ -0,0 +1,6 @@
+ALTER TABLE notification
+    DROP COLUMN IF EXISTS reason;
+
+ALTER TABLE ord
+    DROP COLUMN IF EXISTS status,
+    DROP COLUMN IF EXISTS status_updated_at;
@@ -0,0 +1,6 @@
+ALTER TABLE ord
+    ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'COMPLETED', -- статус заказа в mb-broker
+    ADD COLUMN IF NOT EXISTS status_updated_at TIMESTAMPTZ;
+
+ALTER TABLE notification
+    ADD COLUMN IF NOT EXISTS reason VARCHAR(255); -- причина пропуска уведомления
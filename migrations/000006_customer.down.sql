@@ -0,0 +1,3 @@
+ALTER TABLE ord DROP COLUMN IF EXISTS customer_id;
+
+drop table if exists customer cascade;
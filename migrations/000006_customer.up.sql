@@ -0,0 +1,23 @@
+CREATE TABLE IF NOT EXISTS customer (
+    id BIGSERIAL PRIMARY KEY,
+    phone VARCHAR(20) NOT NULL UNIQUE,                -- нормализованный номер телефона +77XXXXXXXXX
+    first_name VARCHAR(255) NOT NULL DEFAULT '',
+    last_name VARCHAR(255) NOT NULL DEFAULT '',
+    email VARCHAR(255),
+    first_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(), -- время первого заказа
+    last_seen_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),  -- время последнего заказа
+    order_count INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE ord ALTER COLUMN user_name TYPE VARCHAR(255);
+ALTER TABLE ord ADD COLUMN IF NOT EXISTS customer_id BIGINT REFERENCES customer (id);
+CREATE INDEX IF NOT EXISTS ord_customer_id_idx ON ord (customer_id);
+
+INSERT INTO customer (phone, first_name, first_seen_at, last_seen_at, order_count)
+SELECT user_phone, (ARRAY_AGG(user_name ORDER BY created_at DESC))[1], MIN(created_at) AT TIME ZONE '+05', MAX(created_at) AT TIME ZONE '+05', COUNT(*)
+FROM ord
+GROUP BY user_phone
+ON CONFLICT (phone) DO NOTHING;
+
+UPDATE ord o SET customer_id = c.id FROM customer c WHERE c.phone = o.user_phone;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS notification_provider_message_id_idx;
+
+ALTER TABLE notification
+    DROP COLUMN IF EXISTS provider_message_id,
+    DROP COLUMN IF EXISTS response_code,
+    DROP COLUMN IF EXISTS raw_response;
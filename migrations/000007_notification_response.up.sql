@@ -0,0 +1,6 @@
+ALTER TABLE notification
+    ADD COLUMN IF NOT EXISTS provider_message_id VARCHAR(255), -- ID сообщения в Voximplant
+    ADD COLUMN IF NOT EXISTS response_code INT,                -- HTTP-код ответа Voximplant
+    ADD COLUMN IF NOT EXISTS raw_response TEXT;                -- тело ответа Voximplant
+
+CREATE INDEX IF NOT EXISTS notification_provider_message_id_idx ON notification (provider_message_id);
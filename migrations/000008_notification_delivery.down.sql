@@ -0,0 +1,4 @@
+ALTER TABLE notification
+    DROP COLUMN IF EXISTS delivered_at,
+    DROP COLUMN IF EXISTS read_at,
+    DROP COLUMN IF EXISTS undelivered_at;
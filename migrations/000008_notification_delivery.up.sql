@@ -0,0 +1,4 @@
+ALTER TABLE notification
+    ADD COLUMN IF NOT EXISTS delivered_at TIMESTAMPTZ,   -- сообщение доставлено клиенту
+    ADD COLUMN IF NOT EXISTS read_at TIMESTAMPTZ,        -- сообщение прочитано клиентом
+    ADD COLUMN IF NOT EXISTS undelivered_at TIMESTAMPTZ; -- сообщение не доставлено
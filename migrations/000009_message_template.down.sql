@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS message_template;
+
+ALTER TABLE customer
+    DROP COLUMN IF EXISTS locale;
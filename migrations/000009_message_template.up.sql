@@ -0,0 +1,16 @@
+ALTER TABLE customer
+    ADD COLUMN IF NOT EXISTS locale VARCHAR(5); -- язык сообщений клиента: ru, kk
+
+-- шаблоны сообщений Voximplant. Пустые locale, provider, category подходят для любого значения,
+-- из подходящих выбирается самый точный шаблон
+CREATE TABLE IF NOT EXISTS message_template (
+    id BIGSERIAL PRIMARY KEY,
+    locale VARCHAR(5) NOT NULL DEFAULT '',
+    provider VARCHAR(50) NOT NULL DEFAULT '',
+    category VARCHAR(100) NOT NULL DEFAULT '',
+    template_id VARCHAR(100) NOT NULL,           -- message_template_id в Voximplant
+    params JSONB NOT NULL DEFAULT '{}',          -- text_param_values, значения могут содержать {user_name}, {product_name}, ...
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (locale, provider, category)
+);
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS notification_status_next_attempt_at_idx;
+
+UPDATE notification SET status = 'FAILED' WHERE status = 'GAVE_UP';
+
+ALTER TABLE notification
+    DROP COLUMN IF EXISTS attempt_count,
+    DROP COLUMN IF EXISTS next_attempt_at,
+    DROP COLUMN IF EXISTS last_error;
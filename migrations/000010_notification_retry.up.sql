@@ -0,0 +1,10 @@
+ALTER TABLE notification
+    ADD COLUMN IF NOT EXISTS attempt_count INT NOT NULL DEFAULT 1, -- количество попыток отправки
+    ADD COLUMN IF NOT EXISTS next_attempt_at TIMESTAMPTZ,          -- время следующей попытки для FAILED
+    ADD COLUMN IF NOT EXISTS last_error TEXT;                      -- ошибка последней попытки
+
+CREATE INDEX IF NOT EXISTS notification_status_next_attempt_at_idx ON notification (status, next_attempt_at);
+
+-- недавние неудачные отправки повторяются, более старые остаются как есть
+UPDATE notification SET next_attempt_at = NOW()
+WHERE status = 'FAILED' AND created_at > NOW() - INTERVAL '1 day';
@@ -0,0 +1,4 @@
+UPDATE notification SET status = 'FAILED' WHERE status = 'DEFERRED';
+
+ALTER TABLE customer
+    DROP COLUMN IF EXISTS timezone;
@@ -0,0 +1,2 @@
+ALTER TABLE customer
+    ADD COLUMN IF NOT EXISTS timezone VARCHAR(64); -- часовой пояс клиента (IANA), если не задан - определяется по номеру телефона
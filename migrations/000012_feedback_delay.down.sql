@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS feedback_delay;
+
+DROP INDEX IF EXISTS ord_detail_scheduled_at_idx;
+
+ALTER TABLE ord_detail
+    DROP COLUMN IF EXISTS scheduled_at;
+
+ALTER TABLE ord
+    DROP COLUMN IF EXISTS completed_at;
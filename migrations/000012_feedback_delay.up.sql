@@ -0,0 +1,19 @@
+ALTER TABLE ord
+    ADD COLUMN IF NOT EXISTS completed_at TIMESTAMPTZ; -- время завершения заказа во внешнем сервисе
+
+UPDATE ord SET completed_at = created_at AT TIME ZONE '+05' WHERE completed_at IS NULL;
+
+ALTER TABLE ord_detail
+    ADD COLUMN IF NOT EXISTS scheduled_at TIMESTAMPTZ; -- когда запросить отзыв о товаре
+
+UPDATE ord_detail SET scheduled_at = created_at AT TIME ZONE '+05' WHERE scheduled_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS ord_detail_scheduled_at_idx ON ord_detail (scheduled_at);
+
+-- задержка запроса отзыва после завершения заказа по категориям товаров,
+-- пустая категория задает задержку для остальных категорий
+CREATE TABLE IF NOT EXISTS feedback_delay (
+    category VARCHAR(100) PRIMARY KEY,
+    delay_seconds BIGINT NOT NULL CHECK (delay_seconds >= 0),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,3 @@
+UPDATE notification SET status = 'SKIPPED' WHERE status = 'SUPPRESSED';
+
+DROP INDEX IF EXISTS notification_phone_number_sent_at_idx;
@@ -0,0 +1,2 @@
+-- подсчет сообщений, отправленных покупателю, для ограничения частоты
+CREATE INDEX IF NOT EXISTS notification_phone_number_sent_at_idx ON notification (phone_number, sent_at);
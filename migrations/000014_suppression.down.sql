@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS suppression;
+
+UPDATE notification SET status = 'SKIPPED' WHERE status = 'OPTED_OUT';
@@ -0,0 +1,7 @@
+-- покупатели, отказавшиеся от сообщений
+CREATE TABLE IF NOT EXISTS suppression (
+    phone VARCHAR(20) PRIMARY KEY,                -- номер телефона в формате +77XXXXXXXXX
+    reason TEXT,                                  -- причина отказа
+    source VARCHAR(50) NOT NULL,                  -- откуда пришел отказ: api, csv
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS sandbox_message;
+
+UPDATE notification SET status = 'SKIPPED' WHERE status = 'SANDBOXED';
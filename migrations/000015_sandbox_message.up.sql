@@ -0,0 +1,16 @@
+-- сообщения, которые в режиме песочницы не были отправлены в Voximplant,
+-- уведомления о них хранятся со статусом SANDBOXED
+CREATE TABLE IF NOT EXISTS sandbox_message (
+    id BIGSERIAL PRIMARY KEY,
+    phone_number VARCHAR(20) NOT NULL,
+    order_id VARCHAR(50) NOT NULL,          -- ID заказа во внешнем сервисе
+    product_code VARCHAR(100) NOT NULL,
+    template_id VARCHAR(100) NOT NULL,
+    method VARCHAR(10) NOT NULL,            -- запрос к Voximplant, который был бы отправлен
+    url TEXT NOT NULL,
+    body TEXT NOT NULL,                     -- тело запроса без токена доступа
+    reason VARCHAR(50) NOT NULL,            -- почему сообщение не отправлено: sandbox, not allowlisted
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS sandbox_message_phone_number_idx ON sandbox_message (phone_number);
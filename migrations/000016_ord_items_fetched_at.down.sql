@@ -0,0 +1,2 @@
+ALTER TABLE ord
+    DROP COLUMN IF EXISTS items_fetched_at;
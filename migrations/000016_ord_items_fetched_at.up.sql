@@ -0,0 +1,6 @@
+ALTER TABLE ord
+    ADD COLUMN IF NOT EXISTS items_fetched_at TIMESTAMPTZ; -- когда получены товары заказа, в том числе пустой список
+
+UPDATE ord o SET items_fetched_at = o.created_at AT TIME ZONE '+05'
+WHERE o.items_fetched_at IS NULL
+  AND EXISTS (SELECT 1 FROM ord_detail od WHERE od.order_id = o.id);
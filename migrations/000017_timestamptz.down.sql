@@ -0,0 +1,16 @@
+do
+$$
+    begin
+        execute 'ALTER DATABASE ' || current_database() || ' SET timezone = ''+05''';
+    end;
+$$;
+
+ALTER TABLE ord
+    ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE '+05';
+
+ALTER TABLE ord_detail
+    ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE '+05';
+
+ALTER TABLE notification
+    ALTER COLUMN created_at TYPE TIMESTAMP USING created_at AT TIME ZONE '+05',
+    ALTER COLUMN sent_at TYPE TIMESTAMP USING sent_at AT TIME ZONE 'UTC';
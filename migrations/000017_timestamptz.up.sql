@@ -0,0 +1,22 @@
+-- TIMESTAMP без часового пояса сдвигал время: pgx записывает время приложения без зоны,
+-- а NOW() пишет локальное время базы в поясе '+05' из 000001. Колонки из 000001 переводятся
+-- в TIMESTAMPTZ (колонки, добавленные позже, создаются сразу с TIMESTAMPTZ):
+-- created_at заполняется NOW() и читается в поясе '+05', sent_at пишет приложение - в UTC.
+
+ALTER TABLE ord
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE '+05';
+
+ALTER TABLE ord_detail
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE '+05';
+
+ALTER TABLE notification
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ USING created_at AT TIME ZONE '+05',
+    ALTER COLUMN sent_at TYPE TIMESTAMPTZ USING sent_at AT TIME ZONE 'UTC';
+
+-- с TIMESTAMPTZ часовой пояс сессии больше не влияет на хранимое время
+do
+$$
+    begin
+        execute 'ALTER DATABASE ' || current_database() || ' RESET timezone';
+    end;
+$$;
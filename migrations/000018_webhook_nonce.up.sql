@@ -0,0 +1,9 @@
+-- nonce подписанных вебхуков, хранятся до выхода времени запроса из допустимого окна,
+-- общие для всех экземпляров сервиса, чтобы запрос нельзя было повторить
+CREATE TABLE IF NOT EXISTS webhook_nonce (
+    nonce VARCHAR(255) PRIMARY KEY,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS webhook_nonce_expires_at_idx ON webhook_nonce (expires_at);
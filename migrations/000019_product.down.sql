@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS ord_detail_product_code_idx;
+
+DROP TABLE IF EXISTS product;
@@ -0,0 +1,13 @@
+-- каталог товаров: mb-broker отдает только коды товаров заказа, наименование, цена,
+-- категория и SKU товара берутся из каталога по коду товара
+CREATE TABLE IF NOT EXISTS product (
+    product_code VARCHAR(100) PRIMARY KEY,
+    product_name VARCHAR(255) NOT NULL DEFAULT '',
+    unit_price NUMERIC(14, 2) NOT NULL DEFAULT 0 CHECK (unit_price >= 0),
+    category VARCHAR(100) NOT NULL DEFAULT '',
+    merchant_sku VARCHAR(100) NOT NULL DEFAULT '',
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- товары, сохраненные без данных, дополняются из каталога при его заполнении
+CREATE INDEX IF NOT EXISTS ord_detail_product_code_idx ON ord_detail (product_code);